@@ -0,0 +1,510 @@
+package gonfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxElems bounds the number of elements of a slice of structs
+// unless the field sets a `maxlen` tag.
+const defaultMaxElems = 100
+
+// isStructElem reports whether t, a slice or map element type, is loaded as a
+// nested struct (value or pointer) rather than parsed from a single string.
+func (r *registry) isStructElem(t reflect.Type) bool {
+	// Pointer types may have their own parser, like *vm.Program
+	if r.isCustomParsedType(t) {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !r.isCustomParsedType(t)
+}
+
+// collectionPrefix returns the key prefix shared by the elements of a slice or
+// map of structs: the field's `prefix` tag, else its variable name plus "_".
+func collectionPrefix(sf reflect.StructField, prefix keyPrefix, auto bool) string {
+	if p, ok := sf.Tag.Lookup("prefix"); ok {
+		return prefix.explicit + p
+	}
+	return envKey(sf, prefix, auto) + "_"
+}
+
+// loadStructSlice fills a []Struct or []*Struct field from indexed keys.
+// Element i reads its fields behind the prefix base + "<i>_", so with base
+// "UPSTREAMS_" the first element's Host comes from UPSTREAMS_0_HOST.
+// In structured sources the elements are those of the sequence at segs.
+// Elements must be numbered from 0 without gaps and there may be at most
+// `maxlen` of them (default 100).
+func (d *decoder) loadStructSlice(fv reflect.Value, sf reflect.StructField, path, base string, segs []pathSeg) {
+	maxLen := defaultMaxElems
+	if s := sf.Tag.Get("maxlen"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			d.fail(path, base, ErrParse, fmt.Errorf("invalid maxlen tag %q", s))
+			return
+		}
+		maxLen = n
+	}
+
+	elemType := fv.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Pointer {
+		structType = elemType.Elem()
+	}
+
+	present, err := d.sliceIndexes(structType, base, segs, maxLen)
+	if err != nil {
+		d.fail(path, base+"*", ErrSource, err)
+		return
+	}
+
+	if len(present) == 0 {
+		if fv.Len() == 0 && sf.Tag.Get("required") == "true" {
+			d.fail(path, indexPrefix(base, 0)+"*", ErrRequired, nil)
+		}
+		return
+	}
+
+	n := present[len(present)-1] + 1
+	if n > maxLen {
+		d.fail(path, indexPrefix(base, maxLen)+"*", ErrParse, fmt.Errorf("more than %d elements", maxLen))
+		return
+	}
+	if len(present) != n {
+		missing := 0
+		for missing < len(present) && present[missing] == missing {
+			missing++
+		}
+		d.fail(path, indexPrefix(base, missing)+"*", ErrParse,
+			fmt.Errorf("element %d missing: elements must be numbered from 0 without gaps", missing))
+		return
+	}
+
+	slice := reflect.MakeSlice(fv.Type(), n, n)
+	for i := 0; i < n; i++ {
+		elem := slice.Index(i)
+		// Keep values already set on the field, defaults never override them
+		if i < fv.Len() {
+			elem.Set(fv.Index(i))
+		}
+		if elemType.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				elem.Set(reflect.New(structType))
+			}
+			elem = elem.Elem()
+		}
+		d.loadStruct(elem, fmt.Sprintf("%s[%d]", path, i), plainPrefix(indexPrefix(base, i)), appendSeg(segs, pathSeg{name: strconv.Itoa(i)}))
+	}
+	fv.Set(slice)
+}
+
+// sliceIndexes returns the sorted indexes of the elements of a slice of
+// structs held in the sources. Sources that can list their keys report every
+// index they hold, so oversized lists are reported, not truncated; the others
+// are probed for indexes 0 through maxLen, one past the limit.
+func (d *decoder) sliceIndexes(structType reflect.Type, base string, segs []pathSeg, maxLen int) ([]int, error) {
+	leaves := d.structKeys(structType, keyPrefix{}, nil, nil)
+	found := make(map[int]bool)
+	var probeSecrets, probe []Source
+	if secrets := secretKeys(leaves); len(secrets) > 0 {
+		for _, src := range d.secretSources {
+			listed, err := addIndexes(found, src, base, secrets, segs)
+			if err != nil {
+				return nil, err
+			}
+			if !listed {
+				probeSecrets = append(probeSecrets, src)
+			}
+		}
+	}
+	for _, src := range d.sources {
+		listed, err := addIndexes(found, src, base, leaves, segs)
+		if err != nil {
+			return nil, err
+		}
+		if !listed {
+			probe = append(probe, src)
+		}
+	}
+
+	if len(probe)+len(probeSecrets) > 0 {
+		for i := 0; i <= maxLen; i++ {
+			if found[i] {
+				continue
+			}
+			elemSegs := appendSeg(segs, pathSeg{name: strconv.Itoa(i)})
+			keys := d.structKeys(structType, plainPrefix(indexPrefix(base, i)), elemSegs, nil)
+			ok, err := hasAnyKey(probeSecrets, secretKeys(keys), elemSegs)
+			if err == nil && !ok {
+				ok, err = hasAnyKey(probe, keys, elemSegs)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				found[i] = true
+			}
+		}
+	}
+
+	present := make([]int, 0, len(found))
+	for i := range found {
+		present = append(present, i)
+	}
+	sort.Ints(present)
+	return present, nil
+}
+
+// addIndexes adds to found the slice element indexes src holds: those of the
+// sequence at segs in structured sources, and those of keys of the form
+// base + <index> + "_" + leaf in sources listing their keys. It reports
+// whether src could be scanned; RefSources and sources that cannot list their
+// keys are left to probing.
+func addIndexes(found map[int]bool, src Source, base string, leaves []fieldKey, segs []pathSeg) (bool, error) {
+	if _, ok := src.(RefSource); ok {
+		return false, nil
+	}
+	if ps, ok := src.(PathSource); ok {
+		path, ok := sourcePath(segs, ps)
+		if !ok {
+			return true, nil
+		}
+		names, ok, err := ps.Children(path)
+		if err != nil || !ok {
+			return true, err
+		}
+		for _, name := range names {
+			if i, ok := parseIndex(name); ok {
+				found[i] = true
+			}
+		}
+		return true, nil
+	}
+
+	lister, ok := src.(KeyLister)
+	if !ok {
+		return false, nil
+	}
+	list, err := lister.Keys()
+	if err != nil {
+		return true, err
+	}
+	isLeaf := make(map[string]bool, len(leaves))
+	for _, key := range leaves {
+		isLeaf[key.env] = true
+	}
+	for _, key := range list {
+		rest, ok := strings.CutPrefix(key, base)
+		if !ok {
+			continue
+		}
+		index, leaf, ok := strings.Cut(rest, "_")
+		if !ok || !isLeaf[leaf] {
+			continue
+		}
+		if i, ok := parseIndex(index); ok {
+			found[i] = true
+		}
+	}
+	return true, nil
+}
+
+// parseIndex parses a slice index written without sign or leading zeros, the
+// way indexPrefix writes it.
+func parseIndex(s string) (int, bool) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 || strconv.Itoa(i) != s {
+		return 0, false
+	}
+	return i, true
+}
+
+// indexPrefix returns the key prefix of element i of a slice of structs.
+func indexPrefix(base string, i int) string {
+	return base + strconv.Itoa(i) + "_"
+}
+
+// fieldKey names a leaf field in every kind of source: by environment
+// variable name, or by path in structured sources.
+type fieldKey struct {
+	env  string
+	segs []pathSeg
+}
+
+// field returns the struct field the key belongs to, the last of its segs.
+func (k fieldKey) field() *reflect.StructField {
+	return k.segs[len(k.segs)-1].field
+}
+
+// secretKeys returns the keys of `secret` fields, those the secret sources
+// are consulted for.
+func secretKeys(keys []fieldKey) []fieldKey {
+	var secrets []fieldKey
+	for _, key := range keys {
+		if key.field().Tag.Get("secret") != "" {
+			secrets = append(secrets, key)
+		}
+	}
+	return secrets
+}
+
+// structKeys lists the keys loadStruct would look up for a struct of type t
+// behind prefix and segs. Nested slices of structs contribute their first
+// element. visiting guards against recursive types.
+func (d *decoder) structKeys(t reflect.Type, prefix keyPrefix, segs []pathSeg, visiting map[reflect.Type]bool) []fieldKey {
+	if visiting[t] {
+		return nil
+	}
+	if visiting == nil {
+		visiting = make(map[reflect.Type]bool)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var keys []fieldKey
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		ft := sf.Type
+		fieldSegs := appendSeg(segs, pathSeg{field: &sf})
+
+		switch {
+		case hasFormat(sf):
+			keys = append(keys, fieldKey{envKey(sf, prefix, d.autoEnv), fieldSegs})
+		case ft.Kind() == reflect.Struct && !d.reg.isCustomParsedType(ft):
+			keys = append(keys, d.structKeys(ft, nestedPrefix(sf, prefix, d.autoEnv), fieldSegs, visiting)...)
+		case ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct && !d.reg.isCustomParsedType(ft):
+			keys = append(keys, d.structKeys(ft.Elem(), nestedPrefix(sf, prefix, d.autoEnv), fieldSegs, visiting)...)
+		case ft.Kind() == reflect.Slice && d.reg.isStructElem(ft.Elem()) && !d.reg.isCustomParsedType(ft):
+			elem := ft.Elem()
+			if elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+			elemSegs := appendSeg(fieldSegs, pathSeg{name: "0"})
+			keys = append(keys, d.structKeys(elem, plainPrefix(indexPrefix(collectionPrefix(sf, prefix, d.autoEnv), 0)), elemSegs, visiting)...)
+		default:
+			keys = append(keys, fieldKey{envKey(sf, prefix, d.autoEnv), fieldSegs})
+		}
+	}
+	return keys
+}
+
+// anyKey reports whether any of keys is present in the sources, or whether a
+// structured source has anything at all at segs, like an empty mapping.
+// The secret sources count for the keys of `secret` fields.
+func (d *decoder) anyKey(keys []fieldKey, segs []pathSeg) (bool, error) {
+	if secrets := secretKeys(keys); len(secrets) > 0 {
+		if ok, err := hasAnyKey(d.secretSources, secrets, segs); err != nil || ok {
+			return ok, err
+		}
+	}
+	return hasAnyKey(d.sources, keys, segs)
+}
+
+// hasAnyKey is anyKey over the given sources.
+func hasAnyKey(sources []Source, keys []fieldKey, segs []pathSeg) (bool, error) {
+	for _, src := range sources {
+		if rs, ok := src.(RefSource); ok {
+			for _, key := range keys {
+				ref := key.field().Tag.Get(rs.RefTag())
+				if ref == "" {
+					continue
+				}
+				_, ok, err := rs.Lookup(ref)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			continue
+		}
+
+		ps, ok := src.(PathSource)
+		if !ok {
+			for _, key := range keys {
+				_, ok, err := src.Lookup(key.env)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			continue
+		}
+
+		if path, ok := sourcePath(segs, ps); ok {
+			if _, ok, err := ps.Children(path); err != nil || ok {
+				return ok, err
+			}
+		}
+		for _, key := range keys {
+			path, ok := sourcePath(key.segs, ps)
+			if !ok {
+				continue
+			}
+			_, ok, err := ps.LookupPath(path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// loadStructMap fills a map[K]Struct or map[K]*Struct field with one instance
+// per name discovered in the sources. A key belongs to instance NAME when it
+// has the form base + NAME + "_" + <field key>, so with base "DB_" the key
+// DB_PRIMARY_HOST creates the instance "PRIMARY" and sets its Host. In
+// structured sources the instances are the keys of the mapping at segs.
+// Only sources implementing KeyLister or PathSource take part in discovery;
+// once discovered, every instance is loaded from all sources with defaults applied.
+func (d *decoder) loadStructMap(fv reflect.Value, sf reflect.StructField, path, base string, segs []pathSeg) {
+	mapType := fv.Type()
+	elemType := mapType.Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Pointer {
+		structType = elemType.Elem()
+	}
+
+	instances, err := d.discoverInstances(base, d.structKeys(structType, keyPrefix{}, nil, nil), segs)
+	if err != nil {
+		d.fail(path, base+"*", ErrSource, err)
+		return
+	}
+	if len(instances) == 0 {
+		if fv.Len() == 0 && sf.Tag.Get("required") == "true" {
+			d.fail(path, base+"*", ErrRequired, nil)
+		}
+		return
+	}
+
+	m := reflect.MakeMapWithSize(mapType, len(instances))
+	// Keep instances already set on the field, defaults never override them
+	iter := fv.MapRange()
+	for iter.Next() {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+
+	for _, inst := range instances {
+		elemPath := fmt.Sprintf("%s[%s]", path, inst.name)
+		k, err := d.parseValue(inst.name, mapType.Key())
+		if err != nil {
+			d.failParse(elemPath, base+inst.env+"_*", fmt.Errorf("instance name %q: %w", inst.name, err))
+			continue
+		}
+
+		elem := reflect.New(elemType).Elem()
+		if existing := m.MapIndex(k); existing.IsValid() {
+			elem.Set(existing)
+		}
+		target := elem
+		if elemType.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				elem.Set(reflect.New(structType))
+			}
+			target = elem.Elem()
+		}
+		d.loadStruct(target, elemPath, plainPrefix(base+inst.env+"_"), appendSeg(segs, pathSeg{name: inst.name}))
+		m.SetMapIndex(k, elem)
+	}
+	fv.Set(m)
+}
+
+// instance is a map-of-structs entry: its name, which becomes the map key,
+// and the name used in its environment variables.
+type instance struct {
+	name string
+	env  string
+}
+
+// discoverInstances scans listable sources for keys of the form
+// base + NAME + "_" + leaf, and structured sources for the keys of the
+// mapping at segs. It returns the distinct instances sorted by name.
+// The leaves are the keys of the struct's fields, and only those of `secret`
+// fields are looked for in the secret sources.
+// When several leaves match one key the longest wins, so with leaves PORT and
+// ADMIN_PORT the key DB_EU_ADMIN_PORT belongs to instance "EU".
+// A name from a structured source, like "eu-west", is also found in the
+// environment in EnvStyle, as DB_EU_WEST_HOST.
+func (d *decoder) discoverInstances(base string, keys []fieldKey, segs []pathSeg) ([]instance, error) {
+	byEnv := make(map[string]instance)
+	if secrets := secretKeys(keys); len(secrets) > 0 {
+		for _, src := range d.secretSources {
+			if err := addInstances(byEnv, src, base, secrets, segs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, src := range d.sources {
+		if err := addInstances(byEnv, src, base, keys, segs); err != nil {
+			return nil, err
+		}
+	}
+
+	instances := make([]instance, 0, len(byEnv))
+	for _, inst := range byEnv {
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].name < instances[j].name })
+	return instances, nil
+}
+
+// addInstances adds the instances src holds to byEnv, keyed by their name in
+// the environment.
+func addInstances(byEnv map[string]instance, src Source, base string, keys []fieldKey, segs []pathSeg) error {
+	if ps, ok := src.(PathSource); ok {
+		path, ok := sourcePath(segs, ps)
+		if !ok {
+			return nil
+		}
+		names, ok, err := ps.Children(path)
+		if err != nil || !ok {
+			return err
+		}
+		for _, name := range names {
+			env := EnvStyle(name)
+			// Structured names win, they keep their original spelling
+			if inst, seen := byEnv[env]; !seen || inst.name == env {
+				byEnv[env] = instance{name: name, env: env}
+			}
+		}
+		return nil
+	}
+
+	lister, ok := src.(KeyLister)
+	if !ok {
+		return nil
+	}
+	list, err := lister.Keys()
+	if err != nil {
+		return err
+	}
+
+	// Longest leaves first
+	leaves := make([]string, len(keys))
+	for i, key := range keys {
+		leaves[i] = key.env
+	}
+	sort.Slice(leaves, func(i, j int) bool { return len(leaves[i]) > len(leaves[j]) })
+
+	for _, key := range list {
+		rest, ok := strings.CutPrefix(key, base)
+		if !ok {
+			continue
+		}
+		for _, leaf := range leaves {
+			name, ok := strings.CutSuffix(rest, "_"+leaf)
+			if ok && name != "" {
+				if _, seen := byEnv[name]; !seen {
+					byEnv[name] = instance{name: name, env: name}
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package gonfig
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type arrayTestConfig struct {
+	Zones    [3]string        `env:"ARR_ZONES"`
+	Ports    [2]uint16        `env:"ARR_PORTS" default:"80,443"`
+	Backoff  [2]time.Duration `env:"ARR_BACKOFF"`
+	Key      [32]byte         `secret:"ARR_KEY"`
+	Nonce    [4]byte          `env:"ARR_NONCE"`
+	TenantID uuid.UUID        `env:"ARR_TENANT"`
+}
+
+func TestArrayFields(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"ARR_ZONES":   "eu-west-1a, eu-west-1b ,eu-west-1c",
+		"ARR_BACKOFF": "100ms,2s",
+		"ARR_NONCE":   "1,2,3,0xff",
+		"ARR_TENANT":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	}))
+
+	var cfg arrayTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, [3]string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}, cfg.Zones)
+	assert.Equal(t, [2]uint16{80, 443}, cfg.Ports)
+	assert.Equal(t, [2]time.Duration{100 * time.Millisecond, 2 * time.Second}, cfg.Backoff)
+	assert.Equal(t, [4]byte{1, 2, 3, 255}, cfg.Nonce)
+	// uuid.UUID is a [16]byte with its own parser
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", cfg.TenantID.String())
+}
+
+func TestByteArrayEncodings(t *testing.T) {
+	var want [32]byte
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+
+	encodings := map[string]string{
+		"hex":            hex.EncodeToString(want[:]),
+		"base64":         base64.StdEncoding.EncodeToString(want[:]),
+		"base64 raw url": base64.RawURLEncoding.EncodeToString(want[:]),
+	}
+	for name, raw := range encodings {
+		t.Run(name, func(t *testing.T) {
+			var cfg arrayTestConfig
+			require.NoError(t, NewLoader(WithSources(MapSource{"ARR_KEY": raw})).Load(&cfg))
+			assert.Equal(t, want, cfg.Key)
+		})
+	}
+}
+
+func TestArrayLengthMismatch(t *testing.T) {
+	cases := map[string]struct {
+		key, raw, want string
+	}{
+		"too few":        {"ARR_ZONES", "a,b", "invalid [3]string: want 3 elements, got 2"},
+		"too many":       {"ARR_ZONES", "a,b,c,d", "invalid [3]string: want 3 elements, got 4"},
+		"short key":      {"ARR_KEY", hex.EncodeToString(make([]byte, 16)), "want 32 bytes as 64 hex digits"},
+		"long base64":    {"ARR_KEY", base64.StdEncoding.EncodeToString(make([]byte, 33)), "want 32 bytes"},
+		"byte list size": {"ARR_NONCE", "1,2,3", "want 4 bytes"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var cfg arrayTestConfig
+			err := NewLoader(WithSources(MapSource{tc.key: tc.raw})).Load(&cfg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrParse)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestArrayElementParseError(t *testing.T) {
+	var cfg arrayTestConfig
+	err := NewLoader(WithSources(MapSource{"ARR_PORTS": "80,70000"})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), "field Ports")
+}
+
+func TestPrettyStringMasksSecretArrays(t *testing.T) {
+	var cfg arrayTestConfig
+	l := NewLoader(WithSources(MapSource{"ARR_KEY": hex.EncodeToString(make([]byte, 32))}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Contains(t, PrettyString(cfg), `"ARR_KEY": "***"`)
+}
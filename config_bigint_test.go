@@ -98,13 +98,36 @@ func TestBigIntInvalid(t *testing.T) {
 	}
 }
 
+func TestBigIntPrefixedLiterals(t *testing.T) {
+	// Test Go literal syntax: base prefixes and underscores
+	cases := map[string]int64{
+		"0x123":     0x123,
+		"0o755":     0o755,
+		"0b1010":    0b1010,
+		"1_000_000": 1_000_000,
+		"-0xff":     -0xff,
+		"010":       10, // zero-padded decimal, not octal
+	}
+	for raw, want := range cases {
+		os.Setenv("BIG_INT", raw)
+		cfg, err := Load(bigIntTestConfig{})
+		os.Unsetenv("BIG_INT")
+		if err != nil {
+			t.Fatalf("Load(%q) failed: %v", raw, err)
+		}
+		if cfg.BigInt.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("BigInt(%q) = %v; want %d", raw, &cfg.BigInt, want)
+		}
+	}
+}
+
 func TestBigIntInvalidHex(t *testing.T) {
-	// Test hex input (should fail since we only accept base-10)
-	os.Setenv("BIG_INT", "0x123")
+	// Test malformed hex input
+	os.Setenv("BIG_INT", "0x12G")
 	defer os.Unsetenv("BIG_INT")
 	_, err := Load(bigIntTestConfig{})
 	if err == nil {
-		t.Error("Load should have failed with hex big.Int (only base-10 allowed)")
+		t.Error("Load should have failed with malformed hex big.Int")
 	}
 }
 
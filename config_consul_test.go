@@ -0,0 +1,263 @@
+package gonfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsul implements recursive reads of the Consul KV HTTP API, including
+// blocking queries.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	kv      map[string]string
+	changed chan struct{} // closed on every write
+	queries []string
+}
+
+func newFakeConsul(kv map[string]string) *fakeConsul {
+	return &fakeConsul{index: 10, kv: kv, changed: make(chan struct{})}
+}
+
+func (c *fakeConsul) put(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.kv[key] = value
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "acl-token" {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	q := r.URL.Query()
+
+	c.mu.Lock()
+	c.queries = append(c.queries, r.URL.RawQuery)
+	if index, _ := strconv.ParseUint(q.Get("index"), 10, 64); index > 0 && index == c.index {
+		wait, _ := time.ParseDuration(q.Get("wait"))
+		changed := c.changed
+		c.mu.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+		c.mu.Lock()
+	}
+	defer c.mu.Unlock()
+
+	type pair struct {
+		Key   string
+		Value []byte
+	}
+	var pairs []pair
+	for k, v := range c.kv {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, pair{Key: k, Value: []byte(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+type consulUpstream struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+type consulTestConfig struct {
+	Debug bool `env:"DEBUG"`
+	DB    struct {
+		Host     string `env:"HOST"`
+		MaxConns int    `env:"MAX_CONNS" consul:"max-conns" default:"4"`
+	} `prefix:"DB_"`
+	Upstreams []consulUpstream `prefix:"UPSTREAMS_"`
+	Features  map[string]bool  `env:"FEATURES"`
+}
+
+func TestConsulSource(t *testing.T) {
+	consul := newFakeConsul(map[string]string{
+		"app/prod/":                  "",
+		"app/prod/debug":             "true",
+		"app/prod/db/host":           "db.internal",
+		"app/prod/upstreams/0/host":  "a.internal",
+		"app/prod/upstreams/0/port":  "8080",
+		"app/prod/upstreams/1/host":  "b.internal",
+		"app/prod/features/checkout": "true",
+		"app/prod/features/search":   "false",
+		"app/production/debug":       "false",
+		"other/db/host":              "elsewhere",
+	})
+	ts := httptest.NewServer(consul)
+
+	var cc ConsulConfig
+	require.NoError(t, NewLoader(WithPrefix("CONSUL_"), WithSources(MapSource{
+		"CONSUL_HTTP_ADDR":  strings.TrimPrefix(ts.URL, "http://"),
+		"CONSUL_HTTP_TOKEN": "acl-token",
+		"CONSUL_KV_PREFIX":  "app/prod",
+		"CONSUL_WAIT":       "2s",
+	})).Load(&cc))
+
+	ctx := context.Background()
+	src, err := NewConsulSource(ctx, cc)
+	require.NoError(t, err)
+	src.minDelay = 10 * time.Millisecond
+
+	l := NewLoader(WithSources(MapSource{"DB_HOST": "override.internal"}, src))
+	var cfg consulTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, "override.internal", cfg.DB.Host)
+	assert.Equal(t, 4, cfg.DB.MaxConns)
+	assert.Equal(t, []consulUpstream{{Host: "a.internal", Port: 8080}, {Host: "b.internal"}}, cfg.Upstreams)
+	assert.Equal(t, map[string]bool{"checkout": true, "search": false}, cfg.Features)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "consul app/prod/debug", settings["Debug"])
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Contains(t, keys, "upstreams.1.host")
+
+	// The blocking query returns as soon as a key changes
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		consul.put("app/prod/db/max-conns", "16")
+	}()
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, 16, cfg.DB.MaxConns)
+
+	consul.mu.Lock()
+	last := consul.queries[len(consul.queries)-1]
+	consul.mu.Unlock()
+	assert.Contains(t, last, "index=10")
+	assert.Contains(t, last, "wait=2s")
+
+	// A change elsewhere wakes the query without changing the values
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		consul.put("other/db/host", "moved")
+	}()
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// With the agent gone the last values stay in place
+	ts.Close()
+	_, err = src.Refresh(ctx)
+	assert.Error(t, err)
+	v, ok, err := src.Lookup("db.max-conns")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "16", v)
+}
+
+func TestConsulSourceErrors(t *testing.T) {
+	ts := httptest.NewServer(newFakeConsul(map[string]string{}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	_, err := NewConsulSource(ctx, ConsulConfig{Address: ts.URL, Prefix: "app", Token: "wrong"})
+	assert.EqualError(t, err, "consul GET app/: 403 Forbidden: ACL not found")
+
+	// An empty folder is not an error
+	src, err := NewConsulSource(ctx, ConsulConfig{Address: ts.URL, Prefix: "app", Token: "acl-token"})
+	require.NoError(t, err)
+	var cfg consulTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, 4, cfg.DB.MaxConns)
+
+	// Keys that differ only in case, '_' and '-' are kept apart and ambiguous
+	ts2 := httptest.NewServer(newFakeConsul(map[string]string{"app/db/max_conns": "1", "app/db/MAX-CONNS": "2"}))
+	defer ts2.Close()
+	src, err = NewConsulSource(ctx, ConsulConfig{Address: ts2.URL, Prefix: "app", Token: "acl-token"})
+	require.NoError(t, err)
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	assert.ErrorContains(t, err, `"MAX-CONNS" at consul app/db/MAX-CONNS and "max_conns" at consul app/db/max_conns both match "max-conns"`)
+}
+
+func TestConsulSourceDefaultWait(t *testing.T) {
+	consul := newFakeConsul(map[string]string{"app/debug": "false"})
+	ts := httptest.NewServer(consul)
+	defer ts.Close()
+
+	// Built in code, without the struct's defaults
+	ctx := context.Background()
+	src, err := NewConsulSource(ctx, ConsulConfig{
+		Address: ts.URL, Prefix: "app", Token: "acl-token", Timeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// A change after Timeout still ends the blocking query, not the deadline
+	time.AfterFunc(200*time.Millisecond, func() { consul.put("app/debug", "true") })
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	consul.mu.Lock()
+	defer consul.mu.Unlock()
+	assert.Contains(t, consul.queries[1], "wait=5m0s")
+}
+
+func TestConsulSourceWithoutIndex(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		indexes  []string
+	)
+	// A server that never blocks and sends no X-Consul-Index
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		indexes = append(indexes, r.URL.Query().Get("index"))
+		_, _ = w.Write([]byte(`[{"Key":"app/debug","Value":"dHJ1ZQ=="}]`))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	src, err := NewConsulSource(ctx, ConsulConfig{Address: ts.URL, Prefix: "app", Wait: time.Minute})
+	require.NoError(t, err)
+	src.minDelay = 50 * time.Millisecond
+
+	err = Watch(ctx, src, 0, func(err error) { require.NoError(t, err) })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.LessOrEqual(t, requests, 6)
+	assert.Equal(t, "", indexes[0])
+	for _, index := range indexes[1:] {
+		assert.Equal(t, "1", index)
+	}
+}
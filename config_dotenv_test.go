@@ -480,3 +480,41 @@ DOTENV_NOT_REQUIRED=optional_value
 		t.Errorf("Error = %q; want %q", err.Error(), expectedErr)
 	}
 }
+
+// Test that LoadWithDotenv sets the .env variables in the process environment
+func TestLoadWithDotenvSetsEnvironment(t *testing.T) {
+	type Config struct {
+		Name string `env:"DOTENV_SET_NAME"`
+		Mode string `env:"DOTENV_SET_MODE"`
+	}
+
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, ".env")
+	second := filepath.Join(tempDir, ".env.local")
+	if err := os.WriteFile(first, []byte("DOTENV_SET_NAME=first\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .env file: %v", err)
+	}
+	if err := os.WriteFile(second, []byte("DOTENV_SET_NAME=second\nDOTENV_SET_MODE=local\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .env file: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("DOTENV_SET_NAME")
+		os.Unsetenv("DOTENV_SET_MODE")
+	})
+
+	cfg, err := LoadWithDotenv(Config{}, first, second)
+	if err != nil {
+		t.Fatalf("LoadWithDotenv failed: %v", err)
+	}
+	if cfg.Name != "first" || cfg.Mode != "local" {
+		t.Errorf("cfg = %+v; want first file to win and the second to fill the rest", cfg)
+	}
+
+	// The values stay visible to the rest of the process
+	if got := os.Getenv("DOTENV_SET_NAME"); got != "first" {
+		t.Errorf("os.Getenv(DOTENV_SET_NAME) = %q; want %q", got, "first")
+	}
+	if got := os.Getenv("DOTENV_SET_MODE"); got != "local" {
+		t.Errorf("os.Getenv(DOTENV_SET_MODE) = %q; want %q", got, "local")
+	}
+}
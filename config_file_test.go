@@ -0,0 +1,129 @@
+package gonfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeValueFile writes contents to name in a temporary directory with mode perm.
+func writeValueFile(t *testing.T, name, contents string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), perm))
+	// WriteFile is subject to the umask
+	require.NoError(t, os.Chmod(path, perm))
+	return path
+}
+
+type fileTestConfig struct {
+	APIKey   string `secret:"API_KEY" file:"true" required:"true"`
+	Port     int    `env:"PORT" file:"true" default:"8080"`
+	LogLevel string `env:"LOG_LEVEL"`
+}
+
+func TestFileIndirectionPerField(t *testing.T) {
+	key := writeValueFile(t, "api_key", "s3cr3t-value\n", 0o600)
+	level := writeValueFile(t, "log_level", "debug\n", 0o600)
+
+	l := NewLoader(WithSources(MapSource{
+		"API_KEY_FILE":   key,
+		"LOG_LEVEL_FILE": level, // not tagged, so ignored
+	}))
+
+	var cfg fileTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "s3cr3t-value", cfg.APIKey)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Empty(t, cfg.LogLevel)
+}
+
+func TestFileIndirectionGlobal(t *testing.T) {
+	level := writeValueFile(t, "log_level", "debug", 0o600)
+	port := writeValueFile(t, "port", "9090\r\n", 0o600)
+
+	l := NewLoader(
+		WithSources(MapSource{"LOG_LEVEL_PATH": level, "PORT_PATH": port, "API_KEY": "k"}),
+		WithFileIndirection(),
+		WithFileSuffix("_PATH"),
+	)
+
+	var cfg fileTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 9090, cfg.Port)
+}
+
+func TestFileIndirectionTrimsOneNewline(t *testing.T) {
+	key := writeValueFile(t, "api_key", "line1\nline2\n\n", 0o600)
+
+	var cfg fileTestConfig
+	require.NoError(t, NewLoader(WithSources(MapSource{"API_KEY_FILE": key})).Load(&cfg))
+	assert.Equal(t, "line1\nline2\n", cfg.APIKey)
+}
+
+func TestFileIndirectionDirectValueWins(t *testing.T) {
+	key := writeValueFile(t, "api_key", "from-file", 0o600)
+
+	var cfg fileTestConfig
+	l := NewLoader(WithSources(MapSource{"API_KEY": "direct", "API_KEY_FILE": key}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "direct", cfg.APIKey)
+}
+
+func TestFileIndirectionErrors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nope")
+
+		var cfg fileTestConfig
+		err := NewLoader(WithSources(MapSource{"API_KEY_FILE": path})).Load(&cfg)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrSource)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		var fe *FieldError
+		require.ErrorAs(t, err, &fe)
+		assert.Equal(t, "API_KEY_FILE", fe.EnvVar)
+		assert.Contains(t, err.Error(), path)
+	})
+
+	t.Run("parse error hides contents", func(t *testing.T) {
+		port := writeValueFile(t, "port", "not-a-port-s3cret", 0o600)
+
+		var cfg fileTestConfig
+		err := NewLoader(WithSources(MapSource{"API_KEY": "k", "PORT_FILE": port})).Load(&cfg)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrParse)
+		assert.Contains(t, err.Error(), port)
+		assert.NotContains(t, err.Error(), "s3cret")
+	})
+
+	t.Run("required without value or file", func(t *testing.T) {
+		var cfg fileTestConfig
+		err := NewLoader(WithSources(MapSource{})).Load(&cfg)
+		assert.ErrorIs(t, err, ErrRequired)
+	})
+}
+
+func TestStrictFilePerms(t *testing.T) {
+	open := writeValueFile(t, "open", "s3cret", 0o644)
+	private := writeValueFile(t, "private", "s3cret", 0o400)
+
+	var cfg fileTestConfig
+	err := NewLoader(WithSources(MapSource{"API_KEY_FILE": open}), WithStrictFilePerms()).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrSource)
+	assert.Contains(t, err.Error(), "readable by group or others (mode 0644)")
+	assert.NotContains(t, err.Error(), "s3cret")
+
+	require.NoError(t, NewLoader(WithSources(MapSource{"API_KEY_FILE": private}), WithStrictFilePerms()).Load(&cfg))
+	assert.Equal(t, "s3cret", cfg.APIKey)
+
+	// Without the check, group-readable files are accepted
+	cfg = fileTestConfig{}
+	require.NoError(t, NewLoader(WithSources(MapSource{"API_KEY_FILE": open})).Load(&cfg))
+	assert.Equal(t, "s3cret", cfg.APIKey)
+}
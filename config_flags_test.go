@@ -0,0 +1,140 @@
+package gonfig
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flagTestConfig struct {
+	Port     int           `env:"PORT" default:"8080" desc:"port to listen on"`
+	Debug    bool          `env:"DEBUG"`
+	Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+	Tags     []string      `env:"TAGS"`
+	Internal string        `env:"INTERNAL" flag:"-"`
+	Region   string        `env:"REGION" flag:"zone"`
+	DB       struct {
+		Host     string `env:"HOST" default:"localhost" desc:"database host"`
+		MaxConns int    `env:"MAX_CONNS"`
+		Password string `secret:"PASSWORD"`
+	} `prefix:"DB_"`
+}
+
+func newFlagSet(t *testing.T, l *Loader, cfg any) *flag.FlagSet {
+	t.Helper()
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+	require.NoError(t, l.RegisterFlags(fs, cfg))
+	return fs
+}
+
+func TestRegisterFlags(t *testing.T) {
+	var cfg flagTestConfig
+	l := NewLoader(WithSources(MapSource{"PORT": "9000", "DB_HOST": "env-host", "TAGS": "a,b"}))
+	fs := newFlagSet(t, l, &cfg)
+
+	require.NoError(t, fs.Parse([]string{"--db-host", "flag-host", "--debug", "--timeout=1m", "--zone", "eu", "--db-max-conns", "7"}))
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, 9000, cfg.Port, "env applies where no flag is given")
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, time.Minute, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, "eu", cfg.Region)
+	assert.Equal(t, "flag-host", cfg.DB.Host, "flags beat env")
+	assert.Equal(t, 7, cfg.DB.MaxConns)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "flag --db-host", settings["DB.Host"])
+	assert.Equal(t, "map PORT", settings["Port"])
+}
+
+func TestRegisterFlagsUsage(t *testing.T) {
+	var cfg flagTestConfig
+	fs := newFlagSet(t, NewLoader(WithSources(MapSource{})), &cfg)
+
+	port := fs.Lookup("port")
+	require.NotNil(t, port)
+	assert.Equal(t, "port to listen on", port.Usage)
+	assert.Equal(t, "8080", port.DefValue)
+	assert.Equal(t, "database host", fs.Lookup("db-host").Usage)
+
+	assert.Nil(t, fs.Lookup("internal"))
+	assert.Nil(t, fs.Lookup("region"))
+	assert.Nil(t, fs.Lookup("db-password"), "secrets need WithSecretFlags")
+
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	assert.Contains(t, out.String(), "port to listen on (default 8080)")
+}
+
+func TestRegisterFlagsParsesThroughRegistry(t *testing.T) {
+	var cfg flagTestConfig
+	fs := newFlagSet(t, NewLoader(WithSources(MapSource{})), &cfg)
+
+	err := fs.Parse([]string{"--timeout", "soon"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid value "soon" for flag -timeout`)
+}
+
+func TestRegisterFlagsSecrets(t *testing.T) {
+	var cfg flagTestConfig
+	l := NewLoader(
+		WithSources(MapSource{}),
+		WithSecretSources(MapSource{"DB_PASSWORD": "from-secret-store"}),
+		WithSecretFlags(),
+	)
+	fs := newFlagSet(t, l, &cfg)
+
+	require.NoError(t, fs.Parse([]string{"--db-password", "hunter2"}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "hunter2", cfg.DB.Password)
+}
+
+func TestRegisterFlagsErrors(t *testing.T) {
+	l := NewLoader()
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	assert.ErrorContains(t, l.RegisterFlags(fs, &flagTestConfig{}), "flag --port for Port is already defined")
+
+	assert.Error(t, l.RegisterFlags(flag.NewFlagSet("app", flag.ContinueOnError), "not a struct"))
+
+	// A failure defines none of the flags
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("db-host", "", "")
+	assert.ErrorContains(t, l.RegisterFlags(fs, &flagTestConfig{}), "flag --db-host for DB.Host is already defined")
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	assert.Equal(t, []string{"db-host"}, names)
+	assert.Len(t, l.sources, 1)
+
+	type Clash struct {
+		Region string `env:"REGION"`
+		Zone   string `env:"ZONE" flag:"region"`
+	}
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	assert.ErrorContains(t, l.RegisterFlags(fs, &Clash{}), "flag --region for Zone is already defined for Region")
+	assert.Nil(t, fs.Lookup("region"))
+}
+
+func TestFlagName(t *testing.T) {
+	cases := map[string]string{
+		"Port":                "port",
+		"DB.Host":             "db-host",
+		"DB.MaxConns":         "db-max-conns",
+		"APIKey":              "api-key",
+		"Upstreams[0].Host":   "upstreams-0-host",
+		"Replicas[eu-west].X": "replicas-eu-west-x",
+	}
+	for path, want := range cases {
+		assert.Equal(t, want, flagName(path), path)
+	}
+}
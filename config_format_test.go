@@ -0,0 +1,141 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formatRoute struct {
+	Prefix  string `json:"prefix" yaml:"prefix"`
+	Backend string `json:"backend" yaml:"backend"`
+	Token   string `json:"token" yaml:"token" secret:"token"`
+}
+
+type formatTestConfig struct {
+	Routes  []formatRoute            `env:"FMT_ROUTES" format:"json"`
+	Retry   map[string][]int         `env:"FMT_RETRY" format:"json" default:"{\"http\":[1,2,4]}"`
+	Primary *formatRoute             `env:"FMT_PRIMARY" format:"yaml"`
+	Limits  struct{ RPS, Burst int } `env:"FMT_LIMITS" format:"json"`
+	Raw     map[string]any           `env:"FMT_RAW" format:"yaml"`
+	Bogus   []string                 `env:"FMT_BOGUS" format:"toml"`
+}
+
+func TestFormatJSON(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"FMT_ROUTES": `[{"prefix":"/api","backend":"http://api:8080"},{"prefix":"/admin","backend":"http://admin","token":"t0psecret"}]`,
+		"FMT_LIMITS": `{"rps": 100, "burst": 20}`,
+	}))
+
+	var cfg formatTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, []formatRoute{
+		{Prefix: "/api", Backend: "http://api:8080"},
+		{Prefix: "/admin", Backend: "http://admin", Token: "t0psecret"},
+	}, cfg.Routes)
+	assert.Equal(t, map[string][]int{"http": {1, 2, 4}}, cfg.Retry)
+	assert.Equal(t, 100, cfg.Limits.RPS)
+	assert.Equal(t, 20, cfg.Limits.Burst)
+	assert.Nil(t, cfg.Primary)
+}
+
+func TestFormatYAML(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"FMT_PRIMARY": "prefix: /\nbackend: http://web\n",
+		"FMT_RAW":     "{a: 1, b: [x, y]}",
+	}))
+
+	var cfg formatTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	require.NotNil(t, cfg.Primary)
+	assert.Equal(t, formatRoute{Prefix: "/", Backend: "http://web"}, *cfg.Primary)
+	assert.Equal(t, map[string]any{"a": 1, "b": []any{"x", "y"}}, cfg.Raw)
+}
+
+func TestFormatErrors(t *testing.T) {
+	cases := map[string]struct {
+		key, raw, want string
+		kind           error
+	}{
+		"json syntax": {"FMT_ROUTES", `[{"prefix": "/api",}]`, "invalid JSON at offset 20", ErrParse},
+		"json type":   {"FMT_ROUTES", `[{"prefix": 42}]`, "invalid JSON at offset 14", ErrParse},
+		"json trail":  {"FMT_LIMITS", `{"rps": 1} x`, "invalid JSON at offset", ErrParse},
+		"yaml":        {"FMT_PRIMARY", "prefix: [", "invalid YAML", ErrParse},
+		"unknown":     {"FMT_BOGUS", "a = 1", `format "toml"`, ErrUnsupportedType},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var cfg formatTestConfig
+			err := NewLoader(WithSources(MapSource{tc.key: tc.raw})).Load(&cfg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.kind)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestFormatStructuredSources(t *testing.T) {
+	type Config struct {
+		Routes []formatRoute            `format:"json"`
+		Limits struct{ RPS, Burst int } `format:"yaml" default:"{rps: 1}"`
+	}
+
+	// An encoded string is decoded like any other value
+	src, err := YAMLSource([]byte("routes: '[{\"prefix\":\"/api\"}]'\nlimits: '{rps: 5}'\n"))
+	require.NoError(t, err)
+	var cfg Config
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, []formatRoute{{Prefix: "/api"}}, cfg.Routes)
+	assert.Equal(t, 5, cfg.Limits.RPS)
+
+	// A mapping or sequence is not silently dropped
+	cases := map[string]struct {
+		parse func([]byte) (Source, error)
+		doc   string
+		want  string
+	}{
+		"yaml": {YAMLSource, "routes:\n  - prefix: /api\n", "yaml:2:3: want a single json-encoded value, got a mapping or sequence"},
+		"json": {JSONSource, `{"limits": {"rps": 5}}`, "json#/limits: want a single yaml-encoded value, got a mapping or sequence"},
+		"toml": {TOMLSource, "[[routes]]\nprefix = \"/api\"\n", "want a single json-encoded value, got a mapping or sequence"},
+		"ini":  {INISource, "[limits]\nrps = 5\n", "ini:1: want a single yaml-encoded value, got a mapping or sequence"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			src, err := tc.parse([]byte(tc.doc))
+			require.NoError(t, err)
+			var cfg Config
+			err = NewLoader(WithSources(Strict(src))).Load(&cfg)
+			assert.ErrorIs(t, err, ErrParse)
+			assert.ErrorContains(t, err, tc.want)
+			assert.Zero(t, cfg.Limits.Burst)
+		})
+	}
+}
+
+func TestFormatSettingsAndPrettyString(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"FMT_ROUTES": `[{"prefix":"/admin","backend":"http://admin","token":"t0psecret"}]`,
+	}))
+
+	var cfg formatTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	// A formatted field is a single setting, not one per nested field
+	settings := make(map[string]FieldSetting)
+	for _, s := range Settings(cfg) {
+		settings[s.Path] = s
+	}
+	assert.Equal(t, "FMT_LIMITS", settings["Limits"].EnvVar)
+	assert.Equal(t, "json", settings["Limits"].Tags["format"])
+	assert.NotContains(t, settings, "Limits.RPS")
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(PrettyString(cfg)), &out))
+	route := out["FMT_ROUTES"].([]any)[0].(map[string]any)
+	assert.Equal(t, "t0p******", route["token"])
+	assert.Equal(t, "/admin", route["Prefix"])
+}
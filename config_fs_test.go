@@ -0,0 +1,107 @@
+package gonfig
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/fs
+var embeddedDefaults embed.FS
+
+type fsTestConfig struct {
+	Name     string `env:"APP_NAME" yaml:"name" json:"name"`
+	LogLevel string `env:"LOG_LEVEL"`
+	Region   string `env:"REGION"`
+	APIKey   string `secret:"API_KEY" file:"true"`
+	DB       struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	} `prefix:"DB_" yaml:"database" json:"database"`
+}
+
+func TestEmbeddedDefaultsLayer(t *testing.T) {
+	base, err := YAMLFSSource(embeddedDefaults, "testdata/fs/base.yaml")
+	require.NoError(t, err)
+	baseEnv, err := DotenvFSSource(embeddedDefaults, "testdata/fs/base.env")
+	require.NoError(t, err)
+
+	disk, err := JSONSource([]byte(`{"database": {"host": "db.disk"}}`))
+	require.NoError(t, err)
+
+	// env > disk > embedded defaults
+	l := NewLoader(WithSources(MapSource{"REGION": "us-east"}, disk, base, baseEnv))
+	var cfg fsTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "base", cfg.Name)
+	assert.Equal(t, "db.disk", cfg.DB.Host)
+	assert.Equal(t, 5432, cfg.DB.Port)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, "us-east", cfg.Region)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "testdata/fs/base.yaml:4:9", settings["DB.Port"])
+	assert.Equal(t, "dotenv testdata/fs/base.env", settings["LogLevel"])
+}
+
+func TestFSSourcesFromMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/app.json": {Data: []byte(`{"name": "json", "database": {"port": 1}}`)},
+		"conf/app.toml": {Data: []byte("name = \"toml\"\n[db]\nport = 2\n")},
+		"conf/app.ini":  {Data: []byte("name = ini\n[db]\nport = 3\n")},
+	}
+
+	cases := map[string]struct {
+		open func() (Source, error)
+		name string
+		port int
+	}{
+		"json": {func() (Source, error) { return JSONFSSource(fsys, "conf/app.json") }, "json", 1},
+		"toml": {func() (Source, error) { return TOMLFSSource(fsys, "conf/app.toml") }, "toml", 2},
+		"ini":  {func() (Source, error) { return INIFSSource(fsys, "conf/app.ini") }, "ini", 3},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			src, err := tc.open()
+			require.NoError(t, err)
+
+			var cfg fsTestConfig
+			require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+			assert.Equal(t, tc.name, cfg.Name)
+			assert.Equal(t, tc.port, cfg.DB.Port)
+		})
+	}
+
+	_, err := YAMLFSSource(fsys, "conf/missing.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = DotenvFSSource(fsys, ".env")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestFileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"run/secrets/api_key": {Data: []byte("s3cret\n"), Mode: 0o400},
+		"run/secrets/open":    {Data: []byte("s3cret\n"), Mode: 0o644},
+	}
+
+	var cfg fsTestConfig
+	l := NewLoader(WithSources(MapSource{"API_KEY_FILE": "/run/secrets/api_key"}), WithFileFS(fsys), WithStrictFilePerms())
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "s3cret", cfg.APIKey)
+
+	l = NewLoader(WithSources(MapSource{"API_KEY_FILE": "run/secrets/open"}), WithFileFS(fsys), WithStrictFilePerms())
+	err := l.Load(&cfg)
+	assert.ErrorIs(t, err, ErrSource)
+	assert.ErrorContains(t, err, "readable by group or others")
+
+	l = NewLoader(WithSources(MapSource{"API_KEY_FILE": "/run/secrets/nope"}), WithFileFS(fsys))
+	assert.ErrorIs(t, l.Load(&cfg), fs.ErrNotExist)
+}
@@ -0,0 +1,282 @@
+package gonfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configServer serves a JSON document with an ETag, answering 304 to
+// requests that already have it.
+type configServer struct {
+	mu       sync.Mutex
+	doc      string
+	status   int
+	requests []*http.Request
+}
+
+func (s *configServer) set(doc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc = doc
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(s.doc)))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	fmt.Fprint(w, s.doc)
+}
+
+func (s *configServer) last() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+type httpTestConfig struct {
+	LogLevel string `env:"LOG_LEVEL" json:"log_level" default:"info"`
+	DB       struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT" default:"5432"`
+	} `prefix:"DB_" json:"db"`
+}
+
+func TestHTTPSource(t *testing.T) {
+	srv := &configServer{doc: `{"log_level": "debug", "db": {"host": "db.internal"}}`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	// The source's own settings, including its credentials, come from gonfig
+	var remote HTTPConfig
+	require.NoError(t, NewLoader(WithPrefix("CONFIG_"), WithSources(MapSource{
+		"CONFIG_URL":     ts.URL + "/app.json",
+		"CONFIG_TOKEN":   "t0ken",
+		"CONFIG_HEADERS": "X-Tenant=acme",
+	})).Load(&remote))
+
+	ctx := context.Background()
+	src, err := NewHTTPSource(ctx, remote)
+	require.NoError(t, err)
+
+	req := srv.last()
+	assert.Equal(t, "Bearer t0ken", req.Header.Get("Authorization"))
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant"))
+	assert.Empty(t, req.Header.Get("If-None-Match"))
+
+	l := NewLoader(WithSources(MapSource{"DB_PORT": "6432"}, src))
+	var cfg httpTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, 6432, cfg.DB.Port)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, ts.URL+"/app.json#/db/host", settings["DB.Host"])
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.host", "log_level"}, keys)
+
+	// Unchanged: the ETag comes back and the server answers 304
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.NotEmpty(t, srv.last().Header.Get("If-None-Match"))
+
+	srv.set(`{"log_level": "warn", "db": {"host": "db2.internal"}}`)
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, "db2.internal", cfg.DB.Host)
+}
+
+func TestHTTPSourceKeepsLastValuesOnError(t *testing.T) {
+	srv := &configServer{doc: `{"log_level": "debug"}`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	src, err := NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL})
+	require.NoError(t, err)
+
+	srv.mu.Lock()
+	srv.status = http.StatusServiceUnavailable
+	srv.mu.Unlock()
+
+	_, err = src.Refresh(context.Background())
+	assert.ErrorContains(t, err, "503 Service Unavailable")
+	v, ok, err := src.Lookup("log_level")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "debug", v)
+
+	_, err = NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL})
+	assert.Error(t, err)
+	_, err = NewHTTPSource(context.Background(), HTTPConfig{})
+	assert.ErrorContains(t, err, "URL is required")
+}
+
+func TestHTTPSourceTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(&configServer{doc: `{"log_level": "debug"}`})
+	defer ts.Close()
+
+	// Untrusted by default
+	_, err := NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL, Timeout: time.Second})
+	require.Error(t, err)
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600))
+	src, err := NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL, Timeout: time.Second, TLSConfig: TLSConfig{CAFile: ca}})
+	require.NoError(t, err)
+	v, _, _ := src.Lookup("log_level")
+	assert.Equal(t, "debug", v)
+
+	_, err = NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL, TLSConfig: TLSConfig{InsecureSkipVerify: true}})
+	require.NoError(t, err)
+
+	_, err = NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL, TLSConfig: TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPSourceTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	_, err := NewHTTPSource(context.Background(), HTTPConfig{URL: ts.URL, Timeout: 50 * time.Millisecond})
+	assert.ErrorContains(t, err, "Client.Timeout")
+}
+
+// countingRefresher reports a change on every other refresh and fails once.
+type countingRefresher struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *countingRefresher) Refresh(context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls == 1 {
+		return false, fmt.Errorf("unreachable")
+	}
+	return r.calls%2 == 0, nil
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		notes   []error
+		changes int
+	)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, &countingRefresher{}, time.Millisecond, func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			notes = append(notes, err)
+			if err == nil {
+				changes++
+				if changes == 2 {
+					cancel()
+				}
+			}
+		})
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not stop")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, notes, 3)
+	assert.EqualError(t, notes[0], "unreachable")
+	assert.NoError(t, notes[1])
+	assert.NoError(t, notes[2])
+}
+
+// refreshingHost is a string whose parser refreshes the source under test,
+// so a refresh lands between reading one field and the next.
+type refreshingHost string
+
+func TestHTTPSourceRefreshDuringLoad(t *testing.T) {
+	var n atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := n.Add(1)
+		fmt.Fprintf(w, `{"db": {"host": "h%d", "password": "p%d"}}`, i, i)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src, err := NewHTTPSource(ctx, HTTPConfig{URL: ts.URL})
+	require.NoError(t, err)
+
+	// Refresh from another goroutine as well, for the race detector
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			_, _ = src.Refresh(ctx)
+		}
+	}()
+
+	type config struct {
+		DB struct {
+			Host     refreshingHost `json:"host"`
+			Password string         `json:"password"`
+		} `json:"db"`
+	}
+	l := NewLoader(WithSources(src))
+	l.RegisterParser(reflect.TypeOf(refreshingHost("")), func(raw string) (any, error) {
+		_, err := src.Refresh(ctx)
+		return refreshingHost(raw), err
+	})
+	for range 50 {
+		var cfg config
+		require.NoError(t, l.Load(&cfg))
+		// both fields come from the same document
+		require.Equal(t, strings.TrimPrefix(string(cfg.DB.Host), "h"), strings.TrimPrefix(cfg.DB.Password, "p"))
+	}
+	cancel()
+	<-done
+}
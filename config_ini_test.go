@@ -0,0 +1,138 @@
+package gonfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type iniDBConfig struct {
+	Host     string `env:"HOST" default:"localhost"`
+	Port     int    `env:"PORT" default:"5432"`
+	Password string `secret:"PASSWORD"`
+}
+
+type iniTestConfig struct {
+	Name     string      `env:"APP_NAME" ini:"name"`
+	Hosts    []string    `env:"HOSTS" ini:"host"`
+	Motd     string      `env:"MOTD"`
+	LogLevel string      `env:"LOG_LEVEL"`
+	DB       iniDBConfig `prefix:"DB_"`
+	Cache    struct {
+		Redis struct {
+			Addr string `env:"ADDR"`
+		} `prefix:"REDIS_"`
+	} `prefix:"CACHE_"`
+}
+
+const iniTestDoc = `; global settings
+name = "billing \"east\"" ; quoted
+host = a.internal
+host = b.internal
+motd = first line \
+       second line
+log_level = info
+log_level = debug   # the last value wins
+
+[DB]
+host = db.internal
+port: 6432
+password = 'p;a#ss'
+
+# nested sections
+[cache.redis]
+addr = localhost:6379
+`
+
+func TestINIFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	require.NoError(t, os.WriteFile(path, []byte(iniTestDoc), 0o600))
+	src, err := INIFileSource(path)
+	require.NoError(t, err)
+
+	var cfg iniTestConfig
+	l := NewLoader(WithSources(src))
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, `billing "east"`, cfg.Name)
+	assert.Equal(t, []string{"a.internal", "b.internal"}, cfg.Hosts)
+	assert.Equal(t, "first line second line", cfg.Motd)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, iniDBConfig{Host: "db.internal", Port: 6432, Password: "p;a#ss"}, cfg.DB)
+	assert.Equal(t, "localhost:6379", cfg.Cache.Redis.Addr)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, path+":12:7", settings["DB.Port"])
+}
+
+func TestINILayeredUnderEnv(t *testing.T) {
+	src, err := INISource([]byte(iniTestDoc))
+	require.NoError(t, err)
+
+	var cfg iniTestConfig
+	l := NewLoader(WithSources(MapSource{"DB_PORT": "7000", "HOSTS": "c,d"}, src))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, 7000, cfg.DB.Port)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, []string{"c", "d"}, cfg.Hosts)
+}
+
+func TestINIValues(t *testing.T) {
+	src, err := INISource([]byte("host = a, b\r\nname =\r\nmotd = \"tab\\there\" # comment\r\nlog_level = x#y\r\n"))
+	require.NoError(t, err)
+
+	var cfg iniTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts, "a single value is split like any other")
+	assert.Empty(t, cfg.Name)
+	assert.Equal(t, "tab\there", cfg.Motd)
+	assert.Equal(t, "x#y", cfg.LogLevel)
+}
+
+func TestINIBlankContinuation(t *testing.T) {
+	src, err := INISource([]byte("\\\n\n"))
+	require.NoError(t, err)
+	keys, err := src.(KeyLister).Keys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	src, err = INISource([]byte("a = 1\n  \\\n\nb=2\n"))
+	require.NoError(t, err)
+	keys, err = src.(KeyLister).Keys()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, keys)
+}
+
+func TestINIErrors(t *testing.T) {
+	cases := map[string]struct{ doc, want string }{
+		"no separator":   {"[db]\nhost\n", "ini:2: expected key = value"},
+		"missing key":    {" = 1\n", "ini:1: missing key"},
+		"unterminated":   {"[db\n", "ini:1: unterminated section header"},
+		"empty section":  {"[db.]\n", "ini:1: empty section name"},
+		"trailing text":  {"[db] x\n", `ini:1: unexpected "x" after section header`},
+		"open quote":     {"a = \"abc\n", "ini:1:5: unterminated quoted value"},
+		"bad escape":     {"a = \"\\q\"\n", `ini:1:5: invalid escape \q in quoted value`},
+		"after quote":    {"a = 'x' y\n", `ini:1:5: unexpected "y" after quoted value`},
+		"key as section": {"db = 1\n[db]\n", `ini:2: section "db" is already a key`},
+		"key as value":   {"[a.b]\n[a]\nb = 1\n", `ini:3: key "b" is already a section`},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := INISource([]byte(tc.doc))
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+
+	src, err := INISource([]byte("[db]\nport = fifty\n"))
+	require.NoError(t, err)
+	var cfg iniTestConfig
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.ErrorContains(t, err, "ini:2:8")
+}
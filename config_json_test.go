@@ -0,0 +1,173 @@
+package gonfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type jsonDBConfig struct {
+	Host    string        `env:"HOST" default:"localhost"`
+	Port    int           `env:"PORT" default:"5432"`
+	Timeout time.Duration `env:"TIMEOUT" json:"timeout"`
+}
+
+type jsonTestConfig struct {
+	Name      string            `env:"APP_NAME" json:"name"`
+	Memory    resource.Quantity `env:"MEMORY"`
+	Tags      []string          `env:"TAGS"`
+	Ports     [2]int            `env:"PORTS"`
+	Weights   map[string]float64
+	DB        jsonDBConfig   `prefix:"DB_" json:"db"`
+	Upstreams []jsonDBConfig `prefix:"UPSTREAM_"`
+	Enabled   bool           `env:"ENABLED"`
+}
+
+const jsonTestDoc = `{
+  "name": "billing",
+  "memory": "1Gi",
+  "tags": ["a", "b"],
+  "ports": [80, 443],
+  "weights": {"primary": 0.75, "backup": 0.25},
+  "db": {"host": "db.internal", "timeout": "5m"},
+  "upstreams": [{"host": "a.internal", "port": 81}, {"host": "b.internal"}],
+  "enabled": true
+}`
+
+func writeJSON(t *testing.T, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(doc), 0o600))
+	return path
+}
+
+func TestJSONFileSource(t *testing.T) {
+	path := writeJSON(t, jsonTestDoc)
+	src, err := JSONFileSource(path)
+	require.NoError(t, err)
+
+	l := NewLoader(WithSources(src))
+	var cfg jsonTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "billing", cfg.Name)
+	assert.Equal(t, resource.MustParse("1Gi"), cfg.Memory)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, [2]int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]float64{"primary": 0.75, "backup": 0.25}, cfg.Weights)
+	assert.Equal(t, jsonDBConfig{Host: "db.internal", Port: 5432, Timeout: 5 * time.Minute}, cfg.DB)
+	assert.Equal(t, []jsonDBConfig{{Host: "a.internal", Port: 81}, {Host: "b.internal", Port: 5432}}, cfg.Upstreams)
+	assert.True(t, cfg.Enabled)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, path+"#/db/timeout", settings["DB.Timeout"])
+	assert.Equal(t, "default", settings["DB.Port"])
+}
+
+func TestJSONLayeredUnderEnv(t *testing.T) {
+	file, err := JSONSource([]byte(jsonTestDoc))
+	require.NoError(t, err)
+
+	var cfg jsonTestConfig
+	l := NewLoader(WithSources(MapSource{"DB_HOST": "override", "TAGS": "x,y,z"}, file))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "override", cfg.DB.Host)
+	assert.Equal(t, []string{"x", "y", "z"}, cfg.Tags)
+	assert.Equal(t, "billing", cfg.Name)
+}
+
+func TestJSONErrorsCarryPointer(t *testing.T) {
+	path := writeJSON(t, `{"db": {"port": "fifty"}, "ports": [80, "https"], "weights": {"a/b": "heavy"}}`)
+	src, err := JSONFileSource(path)
+	require.NoError(t, err)
+
+	var cfg jsonTestConfig
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), path+"#/db/port")
+	assert.Contains(t, err.Error(), path+"#/ports/1")
+	assert.Contains(t, err.Error(), path+"#/weights/a~1b")
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	assert.Len(t, loadErr.Errors, 3)
+}
+
+func TestJSONSourceErrors(t *testing.T) {
+	_, err := JSONSource([]byte(`{"a": 1,}`))
+	assert.ErrorContains(t, err, "invalid JSON at offset")
+
+	_, err = JSONSource([]byte(`[1, 2]`))
+	assert.ErrorContains(t, err, "top level must be an object")
+
+	_, err = JSONSource([]byte(`{"a": {"b": 1, "b": 2}}`))
+	assert.ErrorContains(t, err, `json#/a/b: duplicate key "b"`)
+
+	// A cut-off document is not an empty one
+	for _, doc := range []string{`{"a":`, `{"db":{"host"`, `{"hosts":["a",{"b":`} {
+		_, err = JSONSource([]byte(doc))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, doc)
+		assert.ErrorContains(t, err, fmt.Sprintf("json: invalid JSON at offset %d", len(doc)), doc)
+	}
+	_, err = JSONSource([]byte(`{"hosts": ["a", "b"`))
+	assert.ErrorContains(t, err, "json: invalid JSON at offset 19")
+
+	_, err = JSONSource([]byte(`{"a": 1} {"b": 2}`))
+	assert.ErrorContains(t, err, "data after top-level object")
+
+	_, err = JSONFileSource(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Keys that differ only in case, '_' and '-' are ambiguous
+	src, err := JSONSource([]byte(`{"db": {"port": 1, "PORT": 2}}`))
+	require.NoError(t, err)
+	var ambiguous jsonTestConfig
+	err = NewLoader(WithSources(src)).Load(&ambiguous)
+	assert.ErrorContains(t, err, `"port" at json#/db/port and "PORT" at json#/db/PORT both match "Port"`)
+
+	empty, err := JSONSource(nil)
+	require.NoError(t, err)
+	var cfg jsonTestConfig
+	require.NoError(t, NewLoader(WithSources(empty)).Load(&cfg))
+}
+
+func TestJSONStrict(t *testing.T) {
+	doc := `{"name": "x", "db": {"prot": 5433, "host": "h"}, "upstreams": [{"hots": "a"}], "extra": {"nested": [1]}}`
+	src, err := JSONSource([]byte(doc))
+	require.NoError(t, err)
+
+	// Lenient by default
+	var cfg jsonTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+
+	err = NewLoader(WithSources(Strict(src))).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	var keys []string
+	for _, fe := range loadErr.Errors {
+		keys = append(keys, fe.Path)
+	}
+	assert.Equal(t, []string{"db.prot", "upstreams.0.hots", "extra.nested.0"}, keys)
+	assert.Contains(t, err.Error(), "json#/db/prot: unknown key")
+
+	// Keys overridden by a higher-precedence source still count as read
+	src, err = JSONSource([]byte(`{"name": "x", "db": {"host": "h"}}`))
+	require.NoError(t, err)
+	l := NewLoader(WithSources(MapSource{"APP_NAME": "env", "DB_HOST": "env"}, Strict(src)))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "env", cfg.DB.Host)
+}
@@ -0,0 +1,140 @@
+package gonfig
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/expr-lang/expr/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitList(t *testing.T) {
+	cases := []struct {
+		raw, sep string
+		want     []string
+	}{
+		{"a, b ,c", ",", []string{"a", "b", "c"}},
+		{"a,,b,", ",", []string{"a", "b"}},
+		{`"a,b",c`, ",", []string{"a,b", "c"}},
+		{` " padded " ,x`, ",", []string{" padded ", "x"}},
+		{`"say ""hi""",x`, ",", []string{`say "hi"`, "x"}},
+		{`"",x`, ",", []string{"", "x"}},
+		{`"Doe, John" <j@x.org>, ops@x.org`, ",", []string{`"Doe, John" <j@x.org>`, "ops@x.org"}},
+		{`a\,b,c`, ",", []string{"a,b", "c"}},
+		{`\d+,\w\\,x`, ",", []string{`\d+`, `\w\`, "x"}},
+		{`\"quoted`, ",", []string{`"quoted`}},
+		{"a,b;c", ";", []string{"a,b", "c"}},
+		{"a::b::c", "::", []string{"a", "b", "c"}},
+		{`"x y" z`, " ", []string{"x y", "z"}},
+	}
+	for _, tc := range cases {
+		got, err := splitList(tc.raw, tc.sep)
+		require.NoError(t, err, tc.raw)
+		assert.Equal(t, tc.want, got, tc.raw)
+	}
+
+	_, err := splitList(`a,"b,c`, ",")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unterminated quote")
+}
+
+func TestNestedListEscapes(t *testing.T) {
+	type Config struct {
+		Groups [][]string `env:"GROUPS"`
+	}
+	cases := map[string][][]string{
+		`a\\,b;c`:     {{`a\`, "b"}, {"c"}}, // an escaped backslash, then a separator
+		`a\,b;c`:      {{"a,b"}, {"c"}},
+		`a\;b,c;d`:    {{"a;b", "c"}, {"d"}},
+		`"x;y",z;w`:   {{"x;y", "z"}, {"w"}},
+		`"a,b";c`:     {{"a,b"}, {"c"}},
+		`\"a,b\";c`:   {{`"a`, `b"`}, {"c"}},
+		`re\d+,x\\;y`: {{`re\d+`, `x\`}, {"y"}},
+	}
+	for raw, want := range cases {
+		var cfg Config
+		require.NoError(t, NewLoader(WithSources(MapSource{"GROUPS": raw})).Load(&cfg), raw)
+		assert.Equal(t, want, cfg.Groups, raw)
+	}
+}
+
+type listTestConfig struct {
+	Hosts    []string       `env:"LIST_HOSTS"`
+	Patterns []string       `env:"LIST_PATTERNS" sep:";"`
+	Admins   []mail.Address `env:"LIST_ADMINS"`
+	Rules    []*vm.Program  `env:"LIST_RULES" sep:";"`
+	Rule     []*vm.Program  `env:"LIST_RULE" split:"false"`
+	Banner   []string       `env:"LIST_BANNER" split:"false"`
+	Matrix   [][]int        `env:"LIST_MATRIX"`
+	Groups   [][]string     `env:"LIST_GROUPS" sep:"|/"`
+	Pairs    [][2]string    `env:"LIST_PAIRS"`
+	Ports    [2]int         `env:"LIST_PORTS" sep:" "`
+	Bad      [][]string     `env:"LIST_BAD" sep:","`
+	Deep     [][][]string   `env:"LIST_DEEP"`
+	Secrets  []string       `secret:"LIST_SECRETS" sep:";"`
+}
+
+func TestListFields(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"LIST_HOSTS":    `a.internal, "b,c.internal"`,
+		"LIST_PATTERNS": `^[a-z]+,[0-9]+$; \d{3}`,
+		"LIST_ADMINS":   `"Doe, John" <john@example.com>, ops@example.com`,
+		"LIST_RULES":    `max(a, b) > 1; a in [1, 2]`,
+		"LIST_RULE":     `max(a, 1) > 1`,
+		"LIST_BANNER":   `Hello, world`,
+		"LIST_MATRIX":   "1,2; 3,4,5",
+		"LIST_GROUPS":   "a/b|c",
+		"LIST_PAIRS":    "k1,v1;k2,v2",
+		"LIST_PORTS":    "80 443",
+	}))
+
+	var cfg listTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, []string{"a.internal", "b,c.internal"}, cfg.Hosts)
+	assert.Equal(t, []string{"^[a-z]+,[0-9]+$", `\d{3}`}, cfg.Patterns)
+
+	require.Len(t, cfg.Admins, 2)
+	assert.Equal(t, "Doe, John", cfg.Admins[0].Name)
+	assert.Equal(t, "john@example.com", cfg.Admins[0].Address)
+	assert.Equal(t, "ops@example.com", cfg.Admins[1].Address)
+
+	assert.Len(t, cfg.Rules, 2)
+	assert.Len(t, cfg.Rule, 1)
+	assert.Equal(t, []string{"Hello, world"}, cfg.Banner)
+
+	assert.Equal(t, [][]int{{1, 2}, {3, 4, 5}}, cfg.Matrix)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, cfg.Groups)
+	assert.Equal(t, [][2]string{{"k1", "v1"}, {"k2", "v2"}}, cfg.Pairs)
+	assert.Equal(t, [2]int{80, 443}, cfg.Ports)
+}
+
+func TestListFieldErrors(t *testing.T) {
+	cases := map[string]struct {
+		key, raw, want string
+		kind           error
+	}{
+		"unterminated quote": {"LIST_HOSTS", `a,"b`, "unterminated quote", ErrParse},
+		"nested single sep":  {"LIST_BAD", "a,b", "must hold two different separators", ErrParse},
+		"three levels":       {"LIST_DEEP", "a", "more than two levels", ErrUnsupportedType},
+		"nested array size":  {"LIST_PAIRS", "k1,v1;k2", "want 2 elements, got 1", ErrParse},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var cfg listTestConfig
+			err := NewLoader(WithSources(MapSource{tc.key: tc.raw})).Load(&cfg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.kind)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestPrettyStringMasksSplitSecrets(t *testing.T) {
+	var cfg listTestConfig
+	l := NewLoader(WithSources(MapSource{"LIST_SECRETS": "token,one;token,two"}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, []string{"token,one", "token,two"}, cfg.Secrets)
+	assert.NotContains(t, PrettyString(cfg), "token,one")
+}
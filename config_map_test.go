@@ -0,0 +1,95 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type mapTestConfig struct {
+	Labels   map[string]string            `env:"MAP_LABELS" default:"app=web,tier=frontend"`
+	Weights  map[string]int               `env:"MAP_WEIGHTS"`
+	Timeouts map[string]time.Duration     `env:"MAP_TIMEOUTS"`
+	Limits   map[string]resource.Quantity `env:"MAP_LIMITS"`
+	Shards   map[uint16]string            `env:"MAP_SHARDS"`
+	Queries  map[string]string            `env:"MAP_QUERIES" sep:";" kvsep:":"`
+	Tokens   map[string]string            `secret:"MAP_TOKENS"`
+	Backends map[string]url.URL           `env:"MAP_BACKENDS"`
+}
+
+func TestMapFields(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"MAP_WEIGHTS":  "a=1, b = 2 ,c=0x10",
+		"MAP_TIMEOUTS": "read=5s,write=1m30s",
+		"MAP_LIMITS":   "cpu=500m,memory=1Gi",
+		"MAP_SHARDS":   "1=eu,2=us",
+		"MAP_QUERIES":  "users:id=1,name=x; orders:status=open",
+	}))
+
+	var cfg mapTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, map[string]string{"app": "web", "tier": "frontend"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 16}, cfg.Weights)
+	assert.Equal(t, map[string]time.Duration{"read": 5 * time.Second, "write": 90 * time.Second}, cfg.Timeouts)
+	assert.Equal(t, map[uint16]string{1: "eu", 2: "us"}, cfg.Shards)
+	assert.Equal(t, map[string]string{"users": "id=1,name=x", "orders": "status=open"}, cfg.Queries)
+
+	cpu := cfg.Limits["cpu"]
+	mem := cfg.Limits["memory"]
+	assert.Equal(t, int64(500), cpu.MilliValue())
+	assert.Equal(t, int64(1<<30), mem.Value())
+}
+
+func TestMapValueContainsKeyValueSeparator(t *testing.T) {
+	var cfg mapTestConfig
+	l := NewLoader(WithSources(MapSource{"MAP_LABELS": "query=a=b"}))
+	require.NoError(t, l.Load(&cfg))
+
+	// Only the first separator splits key from value
+	assert.Equal(t, map[string]string{"query": "a=b"}, cfg.Labels)
+}
+
+func TestMapErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing separator": "a=1,b",
+		"bad value":         "a=one",
+		"duplicate key":     "a=1,a=2",
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			var cfg mapTestConfig
+			err := NewLoader(WithSources(MapSource{"MAP_WEIGHTS": raw})).Load(&cfg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrParse)
+			assert.Contains(t, err.Error(), "field Weights")
+		})
+	}
+
+	var cfg mapTestConfig
+	err := NewLoader(WithSources(MapSource{"MAP_SHARDS": "70000=eu"})).Load(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `map key "70000"`)
+}
+
+func TestPrettyStringMaps(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"MAP_TOKENS":   "github=ghp_abcdef,npm=npm_123456",
+		"MAP_BACKENDS": "main=postgres://user:hunter2@db:5432/app",
+	}))
+
+	var cfg mapTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(PrettyString(cfg)), &out))
+
+	assert.Equal(t, map[string]any{"github": "ghp*******", "npm": "npm*******"}, out["MAP_TOKENS"])
+	assert.Equal(t, map[string]any{"main": "postgres://user:%2A%2A%2A@db:5432/app"}, out["MAP_BACKENDS"])
+	assert.Equal(t, map[string]any{"app": "web", "tier": "frontend"}, out["MAP_LABELS"])
+}
@@ -0,0 +1,135 @@
+package gonfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type numericTestConfig struct {
+	Port      uint16     `env:"NUM_PORT" default:"8080"`
+	ID        uint32     `env:"NUM_ID"`
+	Bytes     uint64     `env:"NUM_BYTES"`
+	Small     uint8      `env:"NUM_SMALL"`
+	Plain     uint       `env:"NUM_PLAIN"`
+	Ptr       uintptr    `env:"NUM_PTR"`
+	Mode      int        `env:"NUM_MODE"`
+	Offset    int16      `env:"NUM_OFFSET"`
+	Ratio     float64    `env:"NUM_RATIO"`
+	Signal    complex128 `env:"NUM_SIGNAL"`
+	Signal64  complex64  `env:"NUM_SIGNAL64"`
+	PortList  []uint16   `env:"NUM_PORT_LIST"`
+	MaskList  []uint8    `env:"NUM_MASK_LIST"`
+	PhaseList []complex64
+}
+
+func TestUnsignedIntegers(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"NUM_ID":        "4294967295",
+		"NUM_BYTES":     "18446744073709551615",
+		"NUM_SMALL":     "255",
+		"NUM_PLAIN":     "42",
+		"NUM_PTR":       "0xdeadbeef",
+		"NUM_PORT_LIST": "80, 443, 8443",
+	}))
+
+	var cfg numericTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, uint16(8080), cfg.Port)
+	assert.Equal(t, uint32(4294967295), cfg.ID)
+	assert.Equal(t, uint64(18446744073709551615), cfg.Bytes)
+	assert.Equal(t, uint8(255), cfg.Small)
+	assert.Equal(t, uint(42), cfg.Plain)
+	assert.Equal(t, uintptr(0xdeadbeef), cfg.Ptr)
+	assert.Equal(t, []uint16{80, 443, 8443}, cfg.PortList)
+}
+
+func TestUnsignedOverflowNamesBitSize(t *testing.T) {
+	cases := []struct {
+		key, raw, want string
+	}{
+		{"NUM_PORT", "70000", `value "70000" out of range for 16-bit unsigned integer`},
+		{"NUM_SMALL", "256", `value "256" out of range for 8-bit unsigned integer`},
+		{"NUM_ID", "0x1_0000_0000", `value "0x1_0000_0000" out of range for 32-bit unsigned integer`},
+		{"NUM_OFFSET", "-40000", `value "-40000" out of range for 16-bit signed integer`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.key, func(t *testing.T) {
+			var cfg numericTestConfig
+			err := NewLoader(WithSources(MapSource{tc.key: tc.raw})).Load(&cfg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrParse)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestUnsignedRejectsNegative(t *testing.T) {
+	var cfg numericTestConfig
+	err := NewLoader(WithSources(MapSource{"NUM_PORT": "-1"})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+}
+
+func TestIntegerLiteralSyntax(t *testing.T) {
+	cases := []struct {
+		raw  string
+		mode int
+	}{
+		{"0x1F", 0x1F},
+		{"0X1f", 0x1F},
+		{"0o755", 0o755},
+		{"0755", 755},
+		{"010", 10},
+		{"-007", -7},
+		{"0b1010", 0b1010},
+		{"1_000_000", 1_000_000},
+		{"-0x10", -0x10},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			src := MapSource{"NUM_MODE": tc.raw}
+			var cfg numericTestConfig
+			require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+			assert.Equal(t, tc.mode, cfg.Mode)
+		})
+	}
+
+	var cfg numericTestConfig
+	src := MapSource{"NUM_MASK_LIST": "0xff, 0b1, 0o17, 1_0"}
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, []uint8{0xff, 1, 0o17, 10}, cfg.MaskList)
+
+	for _, bad := range []string{"1__000", "_1000", "0x", "0b102"} {
+		var cfg numericTestConfig
+		err := NewLoader(WithSources(MapSource{"NUM_MODE": bad})).Load(&cfg)
+		assert.ErrorIs(t, err, ErrParse, bad)
+	}
+}
+
+func TestFloatUnderscores(t *testing.T) {
+	var cfg numericTestConfig
+	require.NoError(t, NewLoader(WithSources(MapSource{"NUM_RATIO": "1_000.5"})).Load(&cfg))
+	assert.Equal(t, 1000.5, cfg.Ratio)
+}
+
+func TestComplexNumbers(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"NUM_SIGNAL":   "1.5+2i",
+		"NUM_SIGNAL64": "(3-4i)",
+		"PhaseList":    "1i, 2, -1-1i",
+	}))
+
+	var cfg numericTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, complex(1.5, 2), cfg.Signal)
+	assert.Equal(t, complex64(complex(3, -4)), cfg.Signal64)
+	assert.Equal(t, []complex64{1i, 2, -1 - 1i}, cfg.PhaseList)
+
+	var bad numericTestConfig
+	err := NewLoader(WithSources(MapSource{"NUM_SIGNAL": "1+"})).Load(&bad)
+	assert.ErrorIs(t, err, ErrParse)
+}
@@ -0,0 +1,243 @@
+package gonfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type prefixPostgresConfig struct {
+	Host     string `env:"HOST" default:"localhost"`
+	Port     int    `env:"PORT" default:"5432"`
+	Password string `secret:"PASSWORD"`
+}
+
+type prefixAppConfig struct {
+	Name    string                `env:"APP_NAME" default:"app"`
+	Primary prefixPostgresConfig  `prefix:"PRIMARY_DB_"`
+	Replica *prefixPostgresConfig `prefix:"REPLICA_DB_"`
+}
+
+func TestPrefixTagOnNestedStructs(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"PRIMARY_DB_HOST":     "primary.internal",
+		"PRIMARY_DB_PASSWORD": "s3cret",
+		"REPLICA_DB_HOST":     "replica.internal",
+		"REPLICA_DB_PORT":     "5433",
+	}))
+
+	var cfg prefixAppConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "primary.internal", cfg.Primary.Host)
+	assert.Equal(t, 5432, cfg.Primary.Port)
+	assert.Equal(t, "s3cret", cfg.Primary.Password)
+	require.NotNil(t, cfg.Replica)
+	assert.Equal(t, "replica.internal", cfg.Replica.Host)
+	assert.Equal(t, 5433, cfg.Replica.Port)
+}
+
+func TestPrefixTagsNest(t *testing.T) {
+	type Pool struct {
+		Size int `env:"SIZE" default:"4"`
+	}
+	type DB struct {
+		Host string `env:"HOST"`
+		Pool Pool   `prefix:"POOL_"`
+	}
+	type Config struct {
+		DB DB `prefix:"DB_"`
+	}
+
+	var cfg Config
+	l := NewLoader(WithSources(MapSource{"DB_HOST": "h", "DB_POOL_SIZE": "16"}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "h", cfg.DB.Host)
+	assert.Equal(t, 16, cfg.DB.Pool.Size)
+}
+
+func TestLoaderWithPrefix(t *testing.T) {
+	l := NewLoader(
+		WithSources(MapSource{
+			"BILLING_APP_NAME":        "billing",
+			"BILLING_PRIMARY_DB_HOST": "billing-db",
+			"APP_NAME":                "ignored",
+		}),
+		WithPrefix("BILLING_"),
+	)
+
+	var cfg prefixAppConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "billing", cfg.Name)
+	assert.Equal(t, "billing-db", cfg.Primary.Host)
+}
+
+func TestLoadWithPrefix(t *testing.T) {
+	t.Setenv("TENANT_A_HOST", "a.internal")
+	t.Setenv("TENANT_B_HOST", "b.internal")
+
+	a, err := LoadWithPrefix(prefixPostgresConfig{}, "TENANT_A_")
+	require.NoError(t, err)
+	b, err := LoadWithPrefix(prefixPostgresConfig{}, "TENANT_B_")
+	require.NoError(t, err)
+
+	assert.Equal(t, "a.internal", a.Host)
+	assert.Equal(t, "b.internal", b.Host)
+}
+
+func TestRequiredErrorNamesPrefixedVariable(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host string `env:"HOST" required:"true"`
+		} `prefix:"DB_"`
+	}
+
+	var cfg Config
+	err := NewLoader(WithSources(MapSource{})).Load(&cfg)
+	require.Error(t, err)
+	assert.Equal(t, `required env "DB_HOST" missing`, err.Error())
+}
+
+func TestAutoEnvNames(t *testing.T) {
+	type Limits struct {
+		MaxConns    int
+		IdleTimeout string
+	}
+	type Embedded struct {
+		LogLevel string
+	}
+	type Config struct {
+		Embedded
+		APIKey      string `secret:"API_KEY"`
+		DatabaseURL string
+		HTTP2Port   int
+		Name        string `env:"SERVICE_NAME"`
+		DB          Limits
+		Cache       Limits `prefix:"REDIS_"`
+	}
+
+	l := NewLoader(
+		WithSources(MapSource{
+			"LOG_LEVEL":       "debug",
+			"API_KEY":         "k",
+			"DATABASE_URL":    "postgres://x",
+			"HTTP2_PORT":      "8443",
+			"SERVICE_NAME":    "svc",
+			"DB_MAX_CONNS":    "10",
+			"DB_IDLE_TIMEOUT": "1m",
+			"REDIS_MAX_CONNS": "20",
+		}),
+		WithAutoEnv(),
+	)
+
+	var cfg Config
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "k", cfg.APIKey)
+	assert.Equal(t, "postgres://x", cfg.DatabaseURL)
+	assert.Equal(t, 8443, cfg.HTTP2Port)
+	assert.Equal(t, "svc", cfg.Name)
+	assert.Equal(t, 10, cfg.DB.MaxConns)
+	assert.Equal(t, "1m", cfg.DB.IdleTimeout)
+	assert.Equal(t, 20, cfg.Cache.MaxConns)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.EnvVar
+	}
+	assert.Equal(t, "DB_MAX_CONNS", settings["DB.MaxConns"])
+	assert.Equal(t, "REDIS_IDLE_TIMEOUT", settings["Cache.IdleTimeout"])
+	assert.Equal(t, "LOG_LEVEL", settings["Embedded.LogLevel"])
+}
+
+func TestAutoEnvKeepsTaggedNames(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host     string `env:"DB_HOST"`
+			Password string `secret:"DB_PASSWORD"`
+			MaxConns int
+			Replica  struct {
+				Host string `env:"HOST"`
+				Port int
+			} `prefix:"REPLICA_"`
+		}
+	}
+
+	l := NewLoader(
+		WithSources(MapSource{
+			"APP_DB_HOST":              "db.internal",
+			"APP_DB_PASSWORD":          "s3cret",
+			"APP_DB_MAX_CONNS":         "10",
+			"APP_REPLICA_HOST":         "replica.internal",
+			"APP_DB_REPLICA_PORT":      "6432",
+			"APP_DB_DB_HOST":           "wrong",
+			"APP_DB_REPLICA_HOST":      "wrong",
+			"APP_DB_REPLICA_MAX_CONNS": "1",
+		}),
+		WithPrefix("APP_"),
+		WithAutoEnv(),
+	)
+
+	var cfg Config
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, "s3cret", cfg.DB.Password)
+	assert.Equal(t, 10, cfg.DB.MaxConns)
+	assert.Equal(t, "replica.internal", cfg.DB.Replica.Host)
+	assert.Equal(t, 6432, cfg.DB.Replica.Port)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.EnvVar
+	}
+	assert.Equal(t, "APP_DB_HOST", settings["DB.Host"])
+	assert.Equal(t, "APP_REPLICA_HOST", settings["DB.Replica.Host"])
+	assert.Equal(t, "APP_DB_REPLICA_PORT", settings["DB.Replica.Port"])
+}
+
+func TestAutoEnvDisabledByDefault(t *testing.T) {
+	type Config struct {
+		MaxConns int
+	}
+
+	var cfg Config
+	l := NewLoader(WithSources(MapSource{"MAX_CONNS": "5", "MaxConns": "7"}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, 7, cfg.MaxConns)
+}
+
+func TestSettingsWithPrefixTags(t *testing.T) {
+	settings := make(map[string]string)
+	for _, s := range Settings(prefixAppConfig{}) {
+		settings[s.Path] = s.EnvVar
+	}
+	assert.Equal(t, "APP_NAME", settings["Name"])
+	assert.Equal(t, "PRIMARY_DB_HOST", settings["Primary.Host"])
+	assert.Equal(t, "REPLICA_DB_PASSWORD", settings["Replica.Password"])
+
+	settings = make(map[string]string)
+	for _, s := range NewLoader(WithPrefix("X_")).Settings(&prefixAppConfig{}) {
+		settings[s.Path] = s.EnvVar
+	}
+	assert.Equal(t, "X_APP_NAME", settings["Name"])
+	assert.Equal(t, "X_PRIMARY_DB_PORT", settings["Primary.Port"])
+}
+
+func TestScreamingSnake(t *testing.T) {
+	cases := map[string]string{
+		"Host":         "HOST",
+		"MaxConns":     "MAX_CONNS",
+		"APIKey":       "API_KEY",
+		"DatabaseURL":  "DATABASE_URL",
+		"UserID":       "USER_ID",
+		"HTTP2Enabled": "HTTP2_ENABLED",
+		"TLS":          "TLS",
+		"already_ok":   "ALREADY_OK",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, screamingSnake(in), in)
+	}
+}
@@ -0,0 +1,195 @@
+package gonfig
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTable is a settings table behind a minimal database/sql driver. Every
+// query returns the rows updated at or after its one argument.
+type fakeTable struct {
+	mu   sync.Mutex
+	rows [][]driver.Value // key, value, updated_at
+	args []time.Time      // argument of each query
+	fail error
+}
+
+func (t *fakeTable) set(key string, value any, updated time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.rows {
+		if r[0] == key {
+			r[1], r[2] = value, updated
+			return
+		}
+	}
+	t.rows = append(t.rows, []driver.Value{key, value, updated})
+}
+
+func (t *fakeTable) Connect(context.Context) (driver.Conn, error) { return fakeConn{t}, nil }
+func (t *fakeTable) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ t *fakeTable }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (fakeConn) Close() error                          { return nil }
+func (fakeConn) Begin() (driver.Tx, error)             { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ t *fakeTable }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return 1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	if s.t.fail != nil {
+		return nil, s.t.fail
+	}
+	since := args[0].(time.Time)
+	s.t.args = append(s.t.args, since)
+	rows := &fakeRows{}
+	for _, r := range s.t.rows {
+		if !r[2].(time.Time).Before(since) {
+			rows.rows = append(rows.rows, r)
+		}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	i    int
+}
+
+func (*fakeRows) Columns() []string { return []string{"key", "value", "updated_at"} }
+func (*fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+type sqlTestConfig struct {
+	MaxUploadMB int    `env:"MAX_UPLOAD_MB" default:"10"`
+	Banner      string `env:"BANNER"`
+	Theme       string `env:"THEME" default:"light"`
+}
+
+func TestSQLSource(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := &fakeTable{}
+	table.set("MAX_UPLOAD_MB", "50", t0)
+	table.set("BANNER", "Welcome", t0.Add(time.Minute))
+	table.set("THEME", nil, t0)
+	db := sql.OpenDB(table)
+	defer db.Close()
+
+	ctx := context.Background()
+	src, err := NewSQLSource(ctx, db, "SELECT key, value, updated_at FROM settings WHERE updated_at >= $1")
+	require.NoError(t, err)
+
+	l := NewLoader(WithSources(MapSource{"BANNER": "from env"}, src))
+	var cfg sqlTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, sqlTestConfig{MaxUploadMB: 50, Banner: "from env", Theme: "light"}, cfg)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "sql MAX_UPLOAD_MB", settings["MaxUploadMB"])
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BANNER", "MAX_UPLOAD_MB"}, keys)
+
+	// Only rows from the newest timestamp on are read again
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	table.set("MAX_UPLOAD_MB", "100", t0.Add(2*time.Minute))
+	table.set("THEME", "dark", t0.Add(2*time.Minute))
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, sqlTestConfig{MaxUploadMB: 100, Banner: "from env", Theme: "dark"}, cfg)
+
+	// A NULL value removes the key
+	table.set("THEME", nil, t0.Add(3*time.Minute))
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	_, ok, err := src.Lookup("THEME")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	table.mu.Lock()
+	assert.Equal(t, []time.Time{{}, t0.Add(time.Minute), t0.Add(time.Minute), t0.Add(2 * time.Minute)}, table.args)
+	table.mu.Unlock()
+}
+
+func TestSQLSourceErrors(t *testing.T) {
+	table := &fakeTable{}
+	db := sql.OpenDB(table)
+	defer db.Close()
+	ctx := context.Background()
+
+	_, err := NewSQLSource(ctx, db, "")
+	assert.EqualError(t, err, "sql source: db and query are required")
+
+	table.set("BANNER", "Welcome", time.Now())
+	src, err := NewSQLSource(ctx, db, "SELECT key, value, updated_at FROM settings WHERE updated_at >= ?")
+	require.NoError(t, err)
+
+	// A failed refresh keeps the values read before
+	table.mu.Lock()
+	table.fail = errors.New("connection refused")
+	table.mu.Unlock()
+	_, err = src.Refresh(ctx)
+	assert.ErrorContains(t, err, "sql source: connection refused")
+	v, ok, err := src.Lookup("BANNER")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Welcome", v)
+}
+
+func TestSQLSourceSnapshot(t *testing.T) {
+	table := &fakeTable{}
+	table.set("BANNER", "Welcome", time.Now())
+	db := sql.OpenDB(table)
+	defer db.Close()
+	ctx := context.Background()
+
+	src, err := NewSQLSource(ctx, db, "SELECT key, value, updated_at FROM settings WHERE updated_at >= ?")
+	require.NoError(t, err)
+	snap := src.Snapshot()
+
+	table.set("BANNER", "Goodbye", time.Now().Add(time.Minute))
+	_, err = src.Refresh(ctx)
+	require.NoError(t, err)
+
+	v, _, err := snap.Lookup("BANNER")
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome", v)
+	v, _, err = src.Lookup("BANNER")
+	require.NoError(t, err)
+	assert.Equal(t, "Goodbye", v)
+}
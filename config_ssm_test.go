@@ -0,0 +1,201 @@
+package gonfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSSM implements GetParametersByPath of the SSM JSON protocol.
+type fakeSSM struct {
+	mu     sync.Mutex
+	params map[string]string // name -> value
+	secure map[string]bool   // SecureString names
+	pages  int
+	auth   []string
+}
+
+func (f *fakeSSM) set(name, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.params[name] = value
+}
+
+func (f *fakeSSM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fail := func(status int, typ, msg string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"__type": typ, "message": msg})
+	}
+	if r.Header.Get("X-Amz-Target") != "AmazonSSM.GetParametersByPath" || r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+		fail(http.StatusBadRequest, "UnknownOperationException", "")
+		return
+	}
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+
+	var in struct {
+		Path           string
+		Recursive      bool
+		WithDecryption bool
+		MaxResults     int
+		NextToken      string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || !in.Recursive {
+		fail(http.StatusBadRequest, "ValidationException", "bad request")
+		return
+	}
+	if strings.HasPrefix(in.Path, "/denied") {
+		fail(http.StatusBadRequest, "com.amazonaws.ssm#AccessDeniedException", "not authorized to perform ssm:GetParametersByPath")
+		return
+	}
+
+	var names []string
+	for name := range f.params {
+		if strings.HasPrefix(name, strings.TrimSuffix(in.Path, "/")+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	start, _ := strconv.Atoi(in.NextToken)
+	end := min(start+in.MaxResults, len(names))
+
+	type param struct{ Name, Type, Value string }
+	out := struct {
+		Parameters []param
+		NextToken  string `json:",omitempty"`
+	}{Parameters: []param{}}
+	for _, name := range names[start:end] {
+		p := param{Name: name, Type: "String", Value: f.params[name]}
+		if f.secure[name] {
+			p.Type = "SecureString"
+			if !in.WithDecryption {
+				p.Value = "AQICAHh...ciphertext"
+			}
+		}
+		out.Parameters = append(out.Parameters, p)
+	}
+	if end < len(names) {
+		out.NextToken = strconv.Itoa(end)
+	}
+	f.pages++
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+type ssmTestConfig struct {
+	LogLevel string `env:"LOG_LEVEL"`
+	DB       struct {
+		Host     string `env:"HOST"`
+		Password string `secret:"PASSWORD"`
+	} `prefix:"DB_"`
+	Features []string          `env:"FEATURES"`
+	Extra    map[string]string `env:"EXTRA"`
+}
+
+func TestSSMSource(t *testing.T) {
+	fake := &fakeSSM{
+		params: map[string]string{
+			"/app/prod/log_level":   "warn",
+			"/app/prod/db/host":     "db.internal",
+			"/app/prod/db/password": "s3cret",
+			"/app/prod/features/0":  "search",
+			"/app/prod/features/1":  "checkout",
+			"/app/staging/db/host":  "staging.internal",
+			"/app/production/x":     "y",
+		},
+		secure: map[string]bool{"/app/prod/db/password": true},
+	}
+	// Enough parameters to span pages of 10
+	for i := range 12 {
+		fake.params["/app/prod/extra/k"+strconv.Itoa(i)] = strconv.Itoa(i)
+	}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	var sc SSMConfig
+	require.NoError(t, NewLoader(WithPrefix("AWS_"), WithSources(MapSource{
+		"AWS_REGION":            "eu-west-1",
+		"AWS_ENDPOINT_URL_SSM":  ts.URL,
+		"AWS_SSM_PATH":          "/app/prod",
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})).Load(&sc))
+
+	ctx := context.Background()
+	src, err := NewSSMSource(ctx, sc)
+	require.NoError(t, err)
+	fake.mu.Lock()
+	assert.Equal(t, 2, fake.pages)
+	assert.True(t, strings.HasPrefix(fake.auth[0], "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), fake.auth[0])
+	assert.Contains(t, fake.auth[0], "/eu-west-1/ssm/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=")
+	fake.mu.Unlock()
+
+	l := NewLoader(WithSources(EnvironSource(nil), src))
+	var cfg ssmTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, "s3cret", cfg.DB.Password)
+	assert.Equal(t, []string{"search", "checkout"}, cfg.Features)
+	assert.Len(t, cfg.Extra, 12)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "ssm /app/prod/db/password", settings["DB.Password"])
+
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	fake.set("/app/prod/db/password", "rotated")
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "rotated", cfg.DB.Password)
+}
+
+func TestSSMSourceErrors(t *testing.T) {
+	ts := httptest.NewServer(&fakeSSM{params: map[string]string{}})
+	defer ts.Close()
+	ctx := context.Background()
+
+	_, err := NewSSMSource(ctx, SSMConfig{Region: "us-east-1", Endpoint: ts.URL, Path: "/denied"})
+	assert.EqualError(t, err, "ssm GetParametersByPath: 400 Bad Request: AccessDeniedException: not authorized to perform ssm:GetParametersByPath")
+
+	src, err := NewSSMSource(ctx, SSMConfig{Region: "us-east-1", Endpoint: ts.URL, Path: "/empty"})
+	require.NoError(t, err)
+
+	// The last values stay when the endpoint goes away
+	ts.Close()
+	_, err = src.Refresh(ctx)
+	assert.Error(t, err)
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// TestSignV4 checks the signature against the get-vanilla case of the AWS
+// Signature Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	cfg := SSMConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, cfg, "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
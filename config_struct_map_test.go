@@ -0,0 +1,167 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type instanceDBConfig struct {
+	Host      string `env:"HOST" required:"true"`
+	Port      int    `env:"PORT" default:"5432"`
+	AdminPort int    `env:"ADMIN_PORT" default:"9187"`
+	Password  string `secret:"PASSWORD"`
+}
+
+type instancesTestConfig struct {
+	Databases map[string]instanceDBConfig  `prefix:"DB_"`
+	Caches    map[string]*instanceDBConfig `env:"CACHE"`
+}
+
+func TestMapOfStructsDiscoversInstances(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"DB_PRIMARY_HOST":        "primary.internal",
+		"DB_PRIMARY_PASSWORD":    "pw",
+		"DB_ANALYTICS_HOST":      "olap.internal",
+		"DB_ANALYTICS_PORT":      "6432",
+		"DB_EU_WEST_HOST":        "eu.internal",  // names may contain underscores
+		"DB_EU_WEST_ADMIN_PORT":  "9999",         // the longest field key wins
+		"DBX_IGNORED_HOST":       "not.a.prefix", // different prefix
+		"CACHE_SESSIONS_HOST":    "redis.internal",
+		"UNRELATED_PRIMARY_HOST": "x",
+	}))
+
+	var cfg instancesTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, map[string]instanceDBConfig{
+		"PRIMARY":   {Host: "primary.internal", Port: 5432, AdminPort: 9187, Password: "pw"},
+		"ANALYTICS": {Host: "olap.internal", Port: 6432, AdminPort: 9187},
+		"EU_WEST":   {Host: "eu.internal", Port: 5432, AdminPort: 9999},
+	}, cfg.Databases)
+
+	require.Contains(t, cfg.Caches, "SESSIONS")
+	assert.Equal(t, "redis.internal", cfg.Caches["SESSIONS"].Host)
+}
+
+func TestMapOfStructsNoInstances(t *testing.T) {
+	var cfg instancesTestConfig
+	require.NoError(t, NewLoader(WithSources(MapSource{})).Load(&cfg))
+	assert.Nil(t, cfg.Databases)
+}
+
+func TestMapOfStructsRequired(t *testing.T) {
+	type Config struct {
+		Databases map[string]instanceDBConfig `prefix:"DB_" required:"true"`
+	}
+
+	var cfg Config
+	err := NewLoader(WithSources(MapSource{})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRequired)
+
+	// An instance discovered through a non-required field still needs its required fields
+	err = NewLoader(WithSources(MapSource{"DB_REPORTING_PORT": "1"})).Load(&cfg)
+	require.Error(t, err)
+	var fe *FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "Databases[REPORTING].Host", fe.Path)
+	assert.Equal(t, "DB_REPORTING_HOST", fe.EnvVar)
+}
+
+func TestMapOfStructsNonStringKeys(t *testing.T) {
+	type Shard struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Shards map[int]Shard `prefix:"SHARD_"`
+	}
+
+	var cfg Config
+	l := NewLoader(WithSources(MapSource{"SHARD_1_HOST": "a", "SHARD_2_HOST": "b"}))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, map[int]Shard{1: {Host: "a"}, 2: {Host: "b"}}, cfg.Shards)
+
+	err := NewLoader(WithSources(MapSource{"SHARD_X_HOST": "a"})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), `instance name "X"`)
+}
+
+func TestMapOfStructsFromProcessEnv(t *testing.T) {
+	t.Setenv("GONFIG_TEST_DB_MAIN_HOST", "env.internal")
+
+	type Config struct {
+		Databases map[string]instanceDBConfig `prefix:"GONFIG_TEST_DB_"`
+	}
+	cfg, err := Load(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "env.internal", cfg.Databases["MAIN"].Host)
+}
+
+func TestMapOfStructsSettingsAndPrettyString(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"DB_PRIMARY_HOST":     "primary.internal",
+		"DB_PRIMARY_PASSWORD": "hunter2",
+		"DB_REPLICA_HOST":     "replica.internal",
+	}))
+
+	var cfg instancesTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	var paths, envs []string
+	for _, s := range Settings(cfg) {
+		paths = append(paths, s.Path)
+		envs = append(envs, s.EnvVar)
+	}
+	assert.Equal(t, []string{
+		"Databases[PRIMARY].Host", "Databases[PRIMARY].Port", "Databases[PRIMARY].AdminPort", "Databases[PRIMARY].Password",
+		"Databases[REPLICA].Host", "Databases[REPLICA].Port", "Databases[REPLICA].AdminPort", "Databases[REPLICA].Password",
+	}, paths)
+	assert.Contains(t, envs, "DB_REPLICA_ADMIN_PORT")
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(PrettyString(cfg)), &out))
+	primary := out["Databases"].(map[string]any)["PRIMARY"].(map[string]any)
+	assert.Equal(t, "hun****", primary["PASSWORD"])
+	assert.Equal(t, "primary.internal", primary["HOST"])
+}
+
+func TestMapOfStructsSettingsMixedCaseNames(t *testing.T) {
+	file, err := YAMLSource([]byte("databases:\n  eu-West:\n    host: eu.internal\n"))
+	require.NoError(t, err)
+	l := NewLoader(WithSources(MapSource{"DB_EU_WEST_PORT": "6432"}, file))
+
+	var cfg instancesTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, instanceDBConfig{Host: "eu.internal", Port: 6432, AdminPort: 9187}, cfg.Databases["eu-West"])
+
+	envs := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		envs[s.Path] = s.EnvVar
+	}
+	assert.Equal(t, "DB_EU_WEST_HOST", envs["Databases[eu-West].Host"])
+	assert.Equal(t, "DB_EU_WEST_PORT", envs["Databases[eu-West].Port"])
+}
+
+func TestMapOfStructsFromSecretSource(t *testing.T) {
+	l := NewLoader(
+		WithSecretSources(MapSource{"DB_REPORTING_PASSWORD": "pw", "DB_IGNORED_HOST": "not a secret"}),
+		WithSources(MapSource{"DB_REPORTING_HOST": "reports.internal"}),
+	)
+	var cfg instancesTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, map[string]instanceDBConfig{
+		"REPORTING": {Host: "reports.internal", Port: 5432, AdminPort: 9187, Password: "pw"},
+	}, cfg.Databases)
+
+	// An instance named only in the secret source
+	l = NewLoader(WithSecretSources(MapSource{"CACHE_SESSIONS_PASSWORD": "pw"}), WithSources(MapSource{}))
+	cfg = instancesTestConfig{}
+	err := l.Load(&cfg)
+	var fe *FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "Caches[SESSIONS].Host", fe.Path)
+}
@@ -0,0 +1,241 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upstreamTestConfig struct {
+	Host   string `env:"HOST" required:"true"`
+	Port   int    `env:"PORT" default:"80"`
+	Weight uint8  `env:"WEIGHT" default:"1"`
+	TLS    bool   `env:"TLS"`
+	Token  string `secret:"TOKEN"`
+}
+
+type proxyTestConfig struct {
+	Listen    string                `env:"LISTEN" default:":8080"`
+	Upstreams []upstreamTestConfig  `env:"UPSTREAMS"`
+	Backups   []*upstreamTestConfig `prefix:"BACKUP_" maxlen:"2"`
+}
+
+func TestSliceOfStructs(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_HOST":   "a.internal",
+		"UPSTREAMS_0_PORT":   "8443",
+		"UPSTREAMS_0_TLS":    "true",
+		"UPSTREAMS_1_HOST":   "b.internal",
+		"UPSTREAMS_1_WEIGHT": "5",
+		"UPSTREAMS_1_TOKEN":  "tok-123456",
+		"BACKUP_0_HOST":      "backup.internal",
+	}))
+
+	var cfg proxyTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, []upstreamTestConfig{
+		{Host: "a.internal", Port: 8443, Weight: 1, TLS: true},
+		{Host: "b.internal", Port: 80, Weight: 5, Token: "tok-123456"},
+	}, cfg.Upstreams)
+
+	require.Len(t, cfg.Backups, 1)
+	assert.Equal(t, &upstreamTestConfig{Host: "backup.internal", Port: 80, Weight: 1}, cfg.Backups[0])
+}
+
+func TestSliceOfStructsEmpty(t *testing.T) {
+	var cfg proxyTestConfig
+	require.NoError(t, NewLoader(WithSources(MapSource{})).Load(&cfg))
+	assert.Nil(t, cfg.Upstreams)
+	assert.Equal(t, ":8080", cfg.Listen)
+}
+
+func TestSliceOfStructsRequiredPerElement(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_HOST": "a.internal",
+		"UPSTREAMS_1_PORT": "81", // element exists but misses its required host
+	}))
+
+	var cfg proxyTestConfig
+	err := l.Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRequired)
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	require.Len(t, loadErr.Errors, 1)
+	assert.Equal(t, "Upstreams[1].Host", loadErr.Errors[0].Path)
+	assert.Equal(t, "UPSTREAMS_1_HOST", loadErr.Errors[0].EnvVar)
+}
+
+func TestSliceOfStructsRequiredField(t *testing.T) {
+	type Config struct {
+		Upstreams []upstreamTestConfig `env:"UPSTREAMS" required:"true"`
+	}
+
+	var cfg Config
+	err := NewLoader(WithSources(MapSource{})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.Contains(t, err.Error(), "UPSTREAMS_0_")
+}
+
+func TestSliceOfStructsGap(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_HOST": "a",
+		"UPSTREAMS_2_HOST": "c",
+	}))
+
+	var cfg proxyTestConfig
+	err := l.Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), "element 1 missing")
+	assert.Nil(t, cfg.Upstreams)
+}
+
+func TestSliceOfStructsMaxLen(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"BACKUP_0_HOST": "a",
+		"BACKUP_1_HOST": "b",
+		"BACKUP_2_HOST": "c",
+	}))
+
+	var cfg proxyTestConfig
+	err := l.Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), "more than 2 elements")
+}
+
+func TestSliceOfStructsIndexAboveMaxLen(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_HOST":   "a",
+		"UPSTREAMS_150_HOST": "b",
+	}))
+
+	var cfg proxyTestConfig
+	err := l.Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), "more than 100 elements")
+	assert.Nil(t, cfg.Upstreams)
+}
+
+// lookupOnlySource is a Source that cannot list its keys.
+type lookupOnlySource map[string]string
+
+func (s lookupOnlySource) Lookup(key string) (string, bool, error) {
+	v, ok := s[key]
+	return v, ok, nil
+}
+
+func TestSliceOfStructsProbesUnlistableSources(t *testing.T) {
+	l := NewLoader(WithSources(
+		MapSource{"BACKUP_0_HOST": "a"},
+		lookupOnlySource{"BACKUP_1_HOST": "b", "BACKUP_2_HOST": "c"},
+	))
+
+	var cfg proxyTestConfig
+	err := l.Load(&cfg)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), "more than 2 elements")
+
+	l = NewLoader(WithSources(
+		MapSource{"BACKUP_0_HOST": "a"},
+		lookupOnlySource{"BACKUP_1_HOST": "b"},
+	))
+	cfg = proxyTestConfig{}
+	require.NoError(t, l.Load(&cfg))
+	require.Len(t, cfg.Backups, 2)
+	assert.Equal(t, "b", cfg.Backups[1].Host)
+}
+
+func TestSliceOfStructsKeepsExistingValues(t *testing.T) {
+	cfg := proxyTestConfig{
+		Upstreams: []upstreamTestConfig{{Host: "preset", Port: 9000}},
+	}
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_TLS":  "true",
+		"UPSTREAMS_1_HOST": "b",
+	}))
+	require.NoError(t, l.Load(&cfg))
+
+	require.Len(t, cfg.Upstreams, 2)
+	assert.Equal(t, "preset", cfg.Upstreams[0].Host)
+	assert.Equal(t, 9000, cfg.Upstreams[0].Port)
+	assert.True(t, cfg.Upstreams[0].TLS)
+	assert.Equal(t, "b", cfg.Upstreams[1].Host)
+}
+
+func TestNestedSliceOfStructs(t *testing.T) {
+	type Route struct {
+		Path string `env:"PATH"`
+	}
+	type Service struct {
+		Name   string  `env:"NAME"`
+		Routes []Route `env:"ROUTES"`
+	}
+	type Config struct {
+		Services []Service `env:"SVC"`
+	}
+
+	l := NewLoader(WithSources(MapSource{
+		"SVC_0_NAME":          "api",
+		"SVC_0_ROUTES_0_PATH": "/v1",
+		"SVC_0_ROUTES_1_PATH": "/v2",
+		"SVC_1_ROUTES_0_PATH": "/", // element 1 exists only through its nested slice
+	}))
+
+	var cfg Config
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, []Service{
+		{Name: "api", Routes: []Route{{Path: "/v1"}, {Path: "/v2"}}},
+		{Routes: []Route{{Path: "/"}}},
+	}, cfg.Services)
+}
+
+func TestSliceOfStructsSettingsAndPrettyString(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"UPSTREAMS_0_HOST":  "a",
+		"UPSTREAMS_0_TOKEN": "secret-token",
+	}))
+
+	var cfg proxyTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	settings := make(map[string]FieldSetting)
+	for _, s := range Settings(cfg) {
+		settings[s.Path] = s
+	}
+	assert.Equal(t, "UPSTREAMS_0_HOST", settings["Upstreams[0].Host"].EnvVar)
+	assert.True(t, settings["Upstreams[0].Token"].Secret)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal([]byte(PrettyString(cfg)), &out))
+	upstreams := out["UPSTREAMS"].([]any)
+	require.Len(t, upstreams, 1)
+	assert.Equal(t, "sec*********", upstreams[0].(map[string]any)["TOKEN"])
+}
+
+func TestSliceOfStructsFromSecretSource(t *testing.T) {
+	type Client struct {
+		Name   string `env:"NAME" default:"anonymous"`
+		Secret string `secret:"SECRET"`
+	}
+	type Config struct {
+		Clients []Client `prefix:"CLIENT_"`
+	}
+
+	// The secret source holds the only key of the element; it is consulted
+	// for secret fields alone, so CLIENT_1_NAME creates no element
+	l := NewLoader(
+		WithSecretSources(MapSource{"CLIENT_0_SECRET": "s3cret", "CLIENT_1_NAME": "ignored"}),
+		WithSources(MapSource{}),
+	)
+	var cfg Config
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, []Client{{Name: "anonymous", Secret: "s3cret"}}, cfg.Clients)
+}
@@ -0,0 +1,157 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeValue converts n to the shape of the JSON in testdata/toml/valid.
+func nodeValue(n *node) any {
+	switch n.kind {
+	case mappingNode:
+		m := make(map[string]any, len(n.keys))
+		for i, k := range n.keys {
+			m[k] = nodeValue(n.items[i])
+		}
+		return m
+	case sequenceNode:
+		items := make([]any, len(n.items))
+		for i, item := range n.items {
+			items[i] = nodeValue(item)
+		}
+		return items
+	case nullNode:
+		return nil
+	}
+	return n.value
+}
+
+func TestTOMLConformanceValid(t *testing.T) {
+	files, err := filepath.Glob("testdata/toml/valid/*.toml")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := TOMLFileSource(file)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(strings.TrimSuffix(file, ".toml") + ".json")
+			require.NoError(t, err)
+			var want any
+			require.NoError(t, json.Unmarshal(data, &want))
+
+			assert.Equal(t, want, nodeValue(src.(*treeSource).root))
+		})
+	}
+}
+
+func TestTOMLConformanceInvalid(t *testing.T) {
+	files, err := filepath.Glob("testdata/toml/invalid/*.toml")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			_, err := TOMLFileSource(file)
+			assert.Error(t, err)
+		})
+	}
+}
+
+type tomlServer struct {
+	Name    string        `env:"NAME" required:"true"`
+	Port    int           `env:"PORT" default:"80"`
+	Timeout time.Duration `env:"TIMEOUT" toml:"timeout" default:"1s"`
+}
+
+type tomlTestConfig struct {
+	Title    string                `env:"TITLE"`
+	Released time.Time             `env:"RELEASED"`
+	Owner    struct{ Name string } `prefix:"OWNER_"`
+	Ports    []int                 `env:"PORTS"`
+	Mask     int                   `env:"MASK"`
+	Ratio    float64               `env:"RATIO"`
+	Servers  []tomlServer          `prefix:"SERVERS_"`
+	Primary  tomlServer            `prefix:"PRIMARY_" toml:"primary"`
+}
+
+const tomlTestDoc = `
+title = "TOML example"
+released = 1979-05-27T07:32:00-08:00
+ports = [8000, 8001]
+mask = 0o755
+ratio = 1e-2
+primary = { name = "gamma", port = 9090 }
+
+[owner]
+name = "Tom"
+
+[[servers]]
+name = "alpha"
+port = 8080
+
+[[servers]]
+name = "beta"
+timeout = "5s"
+`
+
+func TestTOMLFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(tomlTestDoc), 0o600))
+	src, err := TOMLFileSource(path)
+	require.NoError(t, err)
+
+	var cfg tomlTestConfig
+	l := NewLoader(WithSources(src))
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "TOML example", cfg.Title)
+	assert.True(t, cfg.Released.Equal(time.Date(1979, 5, 27, 15, 32, 0, 0, time.UTC)))
+	assert.Equal(t, "Tom", cfg.Owner.Name)
+	assert.Equal(t, []int{8000, 8001}, cfg.Ports)
+	assert.Equal(t, 0o755, cfg.Mask)
+	assert.Equal(t, 0.01, cfg.Ratio)
+	assert.Equal(t, []tomlServer{
+		{Name: "alpha", Port: 8080, Timeout: time.Second},
+		{Name: "beta", Port: 80, Timeout: 5 * time.Second},
+	}, cfg.Servers)
+	assert.Equal(t, tomlServer{Name: "gamma", Port: 9090, Timeout: time.Second}, cfg.Primary)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, path+":3:12", settings["Released"])
+}
+
+func TestTOMLInlineTableAndEnvOverride(t *testing.T) {
+	src, err := TOMLSource([]byte(`primary = { name = "gamma", port = 9090 }` + "\n[owner]\nname = \"Tom\"\n"))
+	require.NoError(t, err)
+
+	var cfg tomlTestConfig
+	l := NewLoader(WithSources(MapSource{"PRIMARY_PORT": "7000", "TITLE": "env"}, src))
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, tomlServer{Name: "gamma", Port: 7000, Timeout: time.Second}, cfg.Primary)
+	assert.Equal(t, "env", cfg.Title)
+	assert.Equal(t, "Tom", cfg.Owner.Name)
+}
+
+func TestTOMLErrors(t *testing.T) {
+	_, err := TOMLSource([]byte("a = 1\n[b]\nc = 2\nc = 3\n"))
+	assert.EqualError(t, err, `toml:4:1: duplicate key "c"`)
+
+	src, err := TOMLSource([]byte("[[servers]]\nname = \"a\"\nport = \"http\"\n"))
+	require.NoError(t, err)
+	var cfg tomlTestConfig
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.ErrorContains(t, err, "toml:3:8")
+}
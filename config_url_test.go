@@ -18,7 +18,7 @@ func TestRegisterParser(t *testing.T) {
 		return CustomType("custom_" + raw), nil
 	})
 
-	if _, ok := customParsers[customType]; !ok {
+	if _, ok := defaultRegistry.snapshot().parsers[customType]; !ok {
 		t.Error("custom parser was not registered")
 	}
 }
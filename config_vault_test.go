@@ -0,0 +1,267 @@
+package gonfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault implements the few endpoints of the Vault HTTP API that
+// VaultSource uses: AppRole login, token lookup and renewal, lease renewal,
+// KV v2 reads and one dynamic credential.
+type fakeVault struct {
+	mu       sync.Mutex
+	tokens   map[string]bool // valid tokens
+	logins   int
+	renewals map[string]int // token renewals by token, lease renewals by lease ID
+	kv       map[string]map[string]any
+	versions map[string]int
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{
+		tokens:   map[string]bool{"root": true},
+		renewals: make(map[string]int),
+		kv:       make(map[string]map[string]any),
+		versions: make(map[string]int),
+	}
+}
+
+// put writes a new version of the KV v2 secret at path.
+func (v *fakeVault) put(path string, data map[string]any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.kv[path] = data
+	v.versions[path]++
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	reply := func(status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	token := r.Header.Get("X-Vault-Token")
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+	if r.Method == http.MethodPost && path == "auth/approle/login" {
+		var creds map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&creds)
+		if creds["role_id"] != "app" || creds["secret_id"] != "s3cret" {
+			reply(http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		v.logins++
+		token := fmt.Sprintf("approle-%d", v.logins)
+		v.tokens[token] = true
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 60, "renewable": true}})
+		return
+	}
+	if !v.tokens[token] {
+		reply(http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "auth/token/lookup-self":
+		reply(http.StatusOK, map[string]any{"data": map[string]any{"ttl": 120, "renewable": true}})
+	case r.Method == http.MethodPost && path == "auth/token/renew-self":
+		v.renewals[token]++
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 120, "renewable": true}})
+	case r.Method == http.MethodPut && path == "sys/leases/renew":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		v.renewals[body["lease_id"]]++
+		reply(http.StatusOK, map[string]any{"lease_id": body["lease_id"], "lease_duration": 60, "renewable": true})
+	case r.Method == http.MethodGet && path == "database/creds/app":
+		reply(http.StatusOK, map[string]any{
+			"lease_id": "database/creds/app/l1", "lease_duration": 60, "renewable": true,
+			"data": map[string]any{"username": "v-app", "password": "dyn-pass"},
+		})
+	case r.Method == http.MethodGet && v.kv[path] != nil:
+		reply(http.StatusOK, map[string]any{"data": map[string]any{
+			"data":     v.kv[path],
+			"metadata": map[string]any{"version": v.versions[path]},
+		}})
+	default:
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+type vaultTestConfig struct {
+	APIKey     string `secret:"API_KEY" vault:"kv/data/app#api_key"`
+	Retries    int    `secret:"RETRIES" vault:"kv/data/app#retries"`
+	DBUser     string `secret:"DB_USER" vault:"database/creds/app#username"`
+	DBPassword string `secret:"DB_PASSWORD" vault:"database/creds/app#password"`
+	Fallback   string `secret:"FALLBACK" vault:"kv/data/other#key" default:"from-default"`
+	LogLevel   string `env:"LOG_LEVEL" default:"info"`
+}
+
+func TestVaultSourceToken(t *testing.T) {
+	vault := newFakeVault()
+	vault.put("kv/data/app", map[string]any{"api_key": "k-1", "retries": 3})
+	ts := httptest.NewServer(vault)
+	defer ts.Close()
+
+	// The source's own settings come from gonfig, under the Vault CLI's names
+	var vc VaultConfig
+	require.NoError(t, NewLoader(WithPrefix("VAULT_"), WithSources(MapSource{
+		"VAULT_ADDR":  ts.URL,
+		"VAULT_TOKEN": "root",
+	})).Load(&vc))
+
+	ctx := context.Background()
+	src, err := NewVaultSource(ctx, vc)
+	require.NoError(t, err)
+
+	l := NewLoader(WithSecretSources(src), WithSources(MapSource{"LOG_LEVEL": "debug"}))
+	var cfg vaultTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, vaultTestConfig{
+		APIKey:     "k-1",
+		Retries:    3,
+		DBUser:     "v-app",
+		DBPassword: "dyn-pass",
+		Fallback:   "from-default",
+		LogLevel:   "debug",
+	}, cfg)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "vault kv/data/app#api_key (version 1)", settings["APIKey"])
+	assert.Equal(t, "vault database/creds/app#password", settings["DBPassword"])
+	assert.Equal(t, "default", settings["Fallback"])
+
+	// Nothing due yet: the KV secret is read again, the lease is left alone
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	vault.put("kv/data/app", map[string]any{"api_key": "k-2", "retries": 3})
+	changed, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "k-2", cfg.APIKey)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "vault kv/data/app#api_key (version 2)", settings["APIKey"])
+	assert.Empty(t, vault.renewals)
+}
+
+func TestVaultSourceAppRoleRenewal(t *testing.T) {
+	vault := newFakeVault()
+	vault.put("kv/data/app", map[string]any{"api_key": "k-1"})
+	ts := httptest.NewServer(vault)
+	defer ts.Close()
+
+	ctx := context.Background()
+	src, err := NewVaultSource(ctx, VaultConfig{Address: ts.URL, RoleID: "app", SecretID: "s3cret", AppRoleMount: "approle"})
+	require.NoError(t, err)
+	now := time.Now()
+	src.now = func() time.Time { return now }
+
+	var cfg vaultTestConfig
+	require.NoError(t, NewLoader(WithSecretSources(src)).Load(&cfg))
+	assert.Equal(t, "k-1", cfg.APIKey)
+	assert.Equal(t, "dyn-pass", cfg.DBPassword)
+
+	// Half of the 60s token and lease are gone: both are renewed
+	now = now.Add(31 * time.Second)
+	changed, err := src.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	vault.mu.Lock()
+	assert.Equal(t, map[string]int{"approle-1": 1, "database/creds/app/l1": 1}, vault.renewals)
+	// The token is revoked behind our back
+	delete(vault.tokens, "approle-1")
+	vault.mu.Unlock()
+
+	// Renewal fails, so the source logs in again
+	now = now.Add(61 * time.Second)
+	_, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	vault.mu.Lock()
+	assert.Equal(t, 2, vault.logins)
+	vault.mu.Unlock()
+
+	v, ok, err := src.Lookup("kv/data/app#api_key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "k-1", v)
+}
+
+func TestVaultSourceErrors(t *testing.T) {
+	vault := newFakeVault()
+	ts := httptest.NewServer(vault)
+	defer ts.Close()
+	ctx := context.Background()
+
+	_, err := NewVaultSource(ctx, VaultConfig{Address: ts.URL, Token: "bogus"})
+	assert.ErrorContains(t, err, "403 Forbidden: permission denied")
+
+	_, err = NewVaultSource(ctx, VaultConfig{Address: ts.URL, RoleID: "app", SecretID: "wrong", AppRoleMount: "approle"})
+	assert.ErrorContains(t, err, "invalid role or secret ID")
+
+	_, err = NewVaultSource(ctx, VaultConfig{Address: ts.URL})
+	assert.ErrorContains(t, err, "Token or RoleID is required")
+
+	// A token without access to a path fails the load
+	src, err := NewVaultSource(ctx, VaultConfig{Address: ts.URL, Token: "root"})
+	require.NoError(t, err)
+	vault.mu.Lock()
+	delete(vault.tokens, "root")
+	vault.mu.Unlock()
+	var cfg vaultTestConfig
+	err = NewLoader(WithSecretSources(src)).Load(&cfg)
+	assert.ErrorIs(t, err, ErrSource)
+	assert.ErrorContains(t, err, "permission denied")
+
+	// References without a key are never present
+	_, ok, err := src.Lookup("API_KEY")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVaultSourceSnapshot(t *testing.T) {
+	vault := newFakeVault()
+	vault.put("kv/data/app", map[string]any{"api_key": "k-1", "retries": 3})
+	ts := httptest.NewServer(vault)
+	defer ts.Close()
+
+	ctx := context.Background()
+	src, err := NewVaultSource(ctx, VaultConfig{Address: ts.URL, Token: "root"})
+	require.NoError(t, err)
+
+	snap := src.Snapshot().(RefSource)
+	v, _, err := snap.Lookup("kv/data/app#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "k-1", v)
+
+	// A new version read by Refresh does not reach the snapshot
+	vault.put("kv/data/app", map[string]any{"api_key": "k-2", "retries": 5})
+	_, err = src.Refresh(ctx)
+	require.NoError(t, err)
+	v, _, err = snap.Lookup("kv/data/app#retries")
+	require.NoError(t, err)
+	assert.Equal(t, "3", v)
+	assert.Equal(t, "vault kv/data/app#retries (version 1)", snap.(Describer).Describe("kv/data/app#retries"))
+
+	v, _, err = src.Lookup("kv/data/app#retries")
+	require.NoError(t, err)
+	assert.Equal(t, "5", v)
+}
@@ -0,0 +1,241 @@
+package gonfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type yamlDBConfig struct {
+	Host     string        `env:"HOST" default:"localhost"`
+	Port     int           `env:"PORT" default:"5432"`
+	MaxConns int           `env:"MAX_CONNS" yaml:"max_conns"`
+	Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+	Password string        `secret:"PASSWORD"`
+}
+
+type yamlUpstream struct {
+	Host   string `env:"HOST" required:"true"`
+	Weight int    `env:"WEIGHT" default:"1"`
+}
+
+type yamlTestConfig struct {
+	Name      string                  `env:"APP_NAME" yaml:"name" default:"app"`
+	Debug     bool                    `env:"DEBUG"`
+	Tags      []string                `env:"TAGS"`
+	Limits    map[string]int          `env:"LIMITS"`
+	Matrix    [][]int                 `env:"MATRIX"`
+	DB        yamlDBConfig            `prefix:"DB_" yaml:"database"`
+	Upstreams []yamlUpstream          `env:"UPSTREAMS"`
+	Replicas  map[string]yamlDBConfig `prefix:"REPLICA_"`
+	Ignored   string                  `env:"IGNORED" yaml:"-"`
+}
+
+const yamlTestDoc = `
+name: billing
+debug: true
+tags: [a, b, c]
+limits:
+  cpu: 2
+  memory: 512
+matrix:
+  - [1, 2]
+  - [3]
+database:
+  host: db.internal
+  max_conns: 20
+  password: hunter2
+upstreams:
+  - host: a.internal
+    weight: 5
+  - host: b.internal
+replicas:
+  eu-west:
+    host: eu.internal
+  us:
+    port: 6432
+ignored: from-file
+`
+
+func writeYAML(t *testing.T, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(doc), 0o600))
+	return path
+}
+
+func TestYAMLFileSource(t *testing.T) {
+	src, err := YAMLFileSource(writeYAML(t, yamlTestDoc))
+	require.NoError(t, err)
+
+	var cfg yamlTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+
+	assert.Equal(t, "billing", cfg.Name)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, []string{"a", "b", "c"}, cfg.Tags)
+	assert.Equal(t, map[string]int{"cpu": 2, "memory": 512}, cfg.Limits)
+	assert.Equal(t, [][]int{{1, 2}, {3}}, cfg.Matrix)
+	assert.Equal(t, yamlDBConfig{Host: "db.internal", Port: 5432, MaxConns: 20, Timeout: 5 * time.Second, Password: "hunter2"}, cfg.DB)
+	assert.Equal(t, []yamlUpstream{{Host: "a.internal", Weight: 5}, {Host: "b.internal", Weight: 1}}, cfg.Upstreams)
+	assert.Equal(t, "eu.internal", cfg.Replicas["eu-west"].Host)
+	assert.Equal(t, 6432, cfg.Replicas["us"].Port)
+	assert.Equal(t, "localhost", cfg.Replicas["us"].Host)
+	assert.Empty(t, cfg.Ignored)
+}
+
+func TestYAMLLayeredUnderEnv(t *testing.T) {
+	file, err := YAMLSource([]byte(yamlTestDoc))
+	require.NoError(t, err)
+
+	l := NewLoader(WithSources(
+		MapSource{
+			"APP_NAME":              "from-env",
+			"DB_PORT":               "7777",
+			"TAGS":                  "x,y",
+			"REPLICA_EU_WEST_PORT":  "1111", // overrides an instance from the file
+			"REPLICA_LOCAL_HOST":    "localhost.internal",
+			"UPSTREAMS_1_WEIGHT":    "9",
+			"IGNORED":               "from-env",
+			"DB_MAX_CONNS":          "40",
+			"REPLICA_US_MAX_CONNS":  "3",
+			"REPLICA_US_PASSWORD":   "p",
+			"UNRELATED_KEY":         "x",
+			"REPLICA__HOST":         "empty name is not an instance",
+			"REPLICA_EU_WEST_DEBUG": "not a field",
+		},
+		file,
+	))
+
+	var cfg yamlTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	// Env beats the file, the file beats defaults
+	assert.Equal(t, "from-env", cfg.Name)
+	assert.Equal(t, 7777, cfg.DB.Port)
+	assert.Equal(t, 40, cfg.DB.MaxConns)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, []string{"x", "y"}, cfg.Tags)
+	assert.Equal(t, "from-env", cfg.Ignored)
+	assert.Equal(t, 9, cfg.Upstreams[1].Weight)
+
+	require.Len(t, cfg.Replicas, 3)
+	assert.Equal(t, 1111, cfg.Replicas["eu-west"].Port)
+	assert.Equal(t, "eu.internal", cfg.Replicas["eu-west"].Host)
+	assert.Equal(t, 3, cfg.Replicas["us"].MaxConns)
+	assert.Equal(t, "localhost.internal", cfg.Replicas["LOCAL"].Host)
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "yaml:12:9", settings["DB.Host"])
+	assert.Equal(t, "map DB_PORT", settings["DB.Port"])
+	assert.Equal(t, "default", settings["DB.Timeout"])
+}
+
+func TestYAMLNamesFromFieldNames(t *testing.T) {
+	type Config struct {
+		MaxConns    int
+		IdleTimeout time.Duration
+		Nested      struct {
+			APIKey string
+		}
+	}
+
+	src, err := YAMLSource([]byte("max_conns: 4\nidleTimeout: 1m\nnested:\n  api-key: k\n"))
+	require.NoError(t, err)
+
+	var cfg Config
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, 4, cfg.MaxConns)
+	assert.Equal(t, time.Minute, cfg.IdleTimeout)
+	assert.Equal(t, "k", cfg.Nested.APIKey)
+}
+
+func TestYAMLAmbiguousNames(t *testing.T) {
+	type Config struct {
+		DBHost string
+		Port   int `yaml:"port"`
+	}
+
+	// An exact match wins over names that only normalize the same
+	src, err := YAMLSource([]byte("port: 1\nPORT: 2\ndb_host: a\n"))
+	require.NoError(t, err)
+	var cfg Config
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, Config{DBHost: "a", Port: 1}, cfg)
+
+	src, err = YAMLSource([]byte("db_host: a\nDB-HOST: b\n"))
+	require.NoError(t, err)
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	assert.ErrorIs(t, err, ErrSource)
+	assert.ErrorContains(t, err, `"db_host" at yaml:1:10 and "DB-HOST" at yaml:2:10 both match "DBHost"`)
+}
+
+func TestYAMLErrorsCarryLineAndColumn(t *testing.T) {
+	path := writeYAML(t, "database:\n  port: fifty\nlimits:\n  cpu: lots\n")
+	src, err := YAMLFileSource(path)
+	require.NoError(t, err)
+
+	var cfg yamlTestConfig
+	err = NewLoader(WithSources(src)).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Contains(t, err.Error(), path+":2:9")
+	assert.Contains(t, err.Error(), path+":4:8")
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	assert.Len(t, loadErr.Errors, 2)
+}
+
+func TestYAMLSourceErrors(t *testing.T) {
+	_, err := YAMLSource([]byte("a: [1, 2"))
+	assert.Error(t, err)
+
+	_, err = YAMLSource([]byte("- just\n- a list\n"))
+	assert.ErrorContains(t, err, "top level must be a mapping")
+
+	_, err = YAMLSource([]byte("a: 1\na: 2\n"))
+	assert.ErrorContains(t, err, `duplicate key "a"`)
+
+	_, err = YAMLFileSource(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestYAMLAnchorsAndMerge(t *testing.T) {
+	src, err := YAMLSource([]byte(`
+base: &base
+  host: shared.internal
+  port: 1000
+database:
+  <<: *base
+  port: 2000
+`))
+	require.NoError(t, err)
+
+	var cfg yamlTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, "shared.internal", cfg.DB.Host)
+	assert.Equal(t, 2000, cfg.DB.Port)
+}
+
+func TestYAMLEmptyAndNullValues(t *testing.T) {
+	src, err := YAMLSource([]byte("name: ~\nupstreams: []\ndatabase:\n"))
+	require.NoError(t, err)
+
+	var cfg yamlTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, "app", cfg.Name)
+	assert.Empty(t, cfg.Upstreams)
+	assert.Equal(t, "localhost", cfg.DB.Host)
+
+	empty, err := YAMLSource(nil)
+	require.NoError(t, err)
+	require.NoError(t, NewLoader(WithSources(empty)).Load(&cfg))
+}
@@ -0,0 +1,209 @@
+package gonfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsulConfig configures a ConsulSource. Loaded with WithPrefix("CONSUL_")
+// it reads the variables the Consul CLI uses, like CONSUL_HTTP_ADDR.
+type ConsulConfig struct {
+	// Address of the agent, like "http://127.0.0.1:8500". Without a scheme,
+	// plain HTTP is used.
+	Address string `env:"HTTP_ADDR" default:"http://127.0.0.1:8500"`
+
+	// Token is sent as X-Consul-Token when set.
+	Token string `secret:"HTTP_TOKEN"`
+
+	// Datacenter to query instead of the agent's own.
+	Datacenter string `env:"DATACENTER"`
+
+	// Prefix is the folder holding the configuration, like "app/prod".
+	Prefix string `env:"KV_PREFIX" required:"true"`
+
+	// Wait bounds how long a blocking query waits for a change; zero means
+	// the agent's default of 5m.
+	Wait time.Duration `env:"WAIT" default:"5m"`
+
+	// Timeout bounds each request beyond the time it is asked to wait.
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+
+	TLSConfig
+}
+
+// ConsulSource is a Source over a folder of a Consul KV store. The keys
+// beneath the folder map onto the config struct by their slash-separated
+// path, so with the prefix "app/prod" the key "app/prod/db/host" fills the
+// field DB.Host. Like INIFileSource, each step is named by the field's
+// `consul` tag or, without one, by its `prefix` tag less the trailing '_' or
+// its name. Keys list the values as dotted keys like "db.host".
+//
+// Refresh runs a blocking query, which returns once the folder changes or
+// Wait runs out, so Watch with an interval of zero learns about changes
+// without polling. While the agent is unreachable the source keeps serving
+// the values it last fetched.
+type ConsulSource struct {
+	cfg    ConsulConfig
+	client *http.Client
+	prefix string // folder with a trailing '/'
+
+	liveTree
+
+	mu     sync.Mutex // guards the fields below
+	index  uint64     // X-Consul-Index of the last response, at least 1
+	values map[string]string
+
+	// A blocking query that returned sooner than minDelay holds back the
+	// next one until minDelay after it started.
+	minDelay time.Duration
+	holdFrom time.Time
+}
+
+// consulMinDelay is the least time between blocking queries that return
+// without blocking, as when the agent sends no index.
+const consulMinDelay = time.Second
+
+// consulDefaultWait is the longest a blocking query waits when Wait is not
+// set, the agent's own default. It is always sent so requests can be
+// bounded by it.
+const consulDefaultWait = 5 * time.Minute
+
+// NewConsulSource fetches the keys under cfg.Prefix and returns a source serving them.
+func NewConsulSource(ctx context.Context, cfg ConsulConfig) (*ConsulSource, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("consul source: Address is required")
+	}
+	if !strings.Contains(cfg.Address, "://") {
+		cfg.Address = "http://" + cfg.Address
+	}
+	if cfg.Wait <= 0 {
+		cfg.Wait = consulDefaultWait
+	}
+	client, err := cfg.client(0) // requests are bounded by their context
+	if err != nil {
+		return nil, fmt.Errorf("consul source: %w", err)
+	}
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	s := &ConsulSource{cfg: cfg, client: client, prefix: prefix, minDelay: consulMinDelay}
+	if _, err := s.fetch(ctx, false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh implements Refresher with a blocking query.
+func (s *ConsulSource) Refresh(ctx context.Context) (bool, error) {
+	return s.fetch(ctx, true)
+}
+
+// fetch reads the keys under the prefix, waiting for them to change past the
+// last index seen when block is set. It reports whether the values changed.
+func (s *ConsulSource) fetch(ctx context.Context, block bool) (bool, error) {
+	s.mu.Lock()
+	index, holdFrom := s.index, s.holdFrom
+	s.mu.Unlock()
+
+	if block && !holdFrom.IsZero() {
+		timer := time.NewTimer(time.Until(holdFrom.Add(s.minDelay)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	start := time.Now()
+
+	query := url.Values{"recurse": {"true"}}
+	if s.cfg.Datacenter != "" {
+		query.Set("dc", s.cfg.Datacenter)
+	}
+	timeout := s.cfg.Timeout
+	if block {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", s.cfg.Wait.String())
+		// the agent adds up to wait/16 of jitter
+		timeout += s.cfg.Wait + s.cfg.Wait/16
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	endpoint := strings.TrimSuffix(s.cfg.Address, "/") + "/v1/kv/" + s.prefix + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", s.cfg.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	// 404 means there are no keys under the prefix
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(msg)); text != "" {
+			return false, fmt.Errorf("consul GET %s: %s: %s", s.prefix, resp.Status, text)
+		}
+		return false, fmt.Errorf("consul GET %s: %s", s.prefix, resp.Status)
+	}
+	var pairs []struct {
+		Key   string
+		Value []byte // base64 in the JSON
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+			return false, fmt.Errorf("consul GET %s: %w", s.prefix, err)
+		}
+	}
+
+	values := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		key := strings.TrimPrefix(p.Key, s.prefix)
+		if key == "" || strings.HasSuffix(key, "/") { // folders
+			continue
+		}
+		values[key] = string(p.Value)
+	}
+
+	// The index must only move forward and never be 0, which would not
+	// block; start over at 1 when it goes back, as after a restored snapshot.
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if newIndex < 1 || newIndex < index {
+		newIndex = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = newIndex
+	s.holdFrom = time.Time{}
+	if block && time.Since(start) < s.minDelay {
+		s.holdFrom = start
+	}
+	if s.values != nil && maps.Equal(values, s.values) {
+		return false, nil
+	}
+	s.values = values
+	root := keyTree(values, func(key string) string { return "consul " + s.prefix + key })
+	s.set(&treeSource{tag: "consul", root: root, prefixNames: true})
+	return true, nil
+}
@@ -21,8 +21,11 @@
 // # Supported Types
 //
 // The library supports a wide range of Go types:
-//   - Basic types: string, bool, int (all sizes), float32, float64
-//   - Collections: slices of supported types
+//   - Basic types: string, bool, int and uint (all sizes), float32, float64, complex64, complex128
+//   - Integer literals in Go syntax: 0x1F, 0o755, 0b1010, 1_000_000
+//   - Collections: slices of supported types, maps written as "key=value,key2=value2"
+//   - Nested slices: [][]string written as "a,b;c,d"
+//   - Fixed-size arrays: [3]string needs exactly 3 elements; [32]byte also accepts hex or base64
 //   - Time types: time.Duration, time.Time
 //   - Network types: net.IP, mail.Address, url.URL
 //   - Crypto types: rsa.PrivateKey, ecdsa.PrivateKey (from PEM format)
@@ -31,6 +34,8 @@
 //   - Expression language: vm.Program (expr-lang/expr for business rules and validation)
 //   - Any type implementing encoding.TextUnmarshaler
 //   - Nested structs (recursive processing)
+//   - Slices of structs from indexed variables
+//   - Maps of structs from named instances
 //
 // # Struct Tags
 //
@@ -39,6 +44,15 @@
 //   - `secret:"VAR_NAME"` - Maps field to environment variable but masks it in output
 //   - `default:"value"` - Provides fallback value when environment variable is not set
 //   - `required:"true"` - Makes field required (fails if not set and no default)
+//   - `prefix:"DB_"` - On a nested struct field, prepends DB_ to every variable inside it
+//   - `maxlen:"N"` - Maximum number of elements of a slice of structs (default 100)
+//   - `sep:";"` - Separator between slice elements or map entries (default ","); `sep:";,"` for [][]T
+//   - `split:"false"` - Loads a slice from the whole value as a single element
+//   - `format:"json"` or `format:"yaml"` - Decodes the whole value into the field, whatever its type
+//   - `file:"true"` - Reads the value from the file named by <VAR_NAME>_FILE when VAR_NAME is not set
+//   - `kvsep:":"` - Separator between a map key and its value (default "=")
+//   - `desc:"text"` - Usage text of the field's command-line flag; see Loader.RegisterFlags
+//   - `flag:"name"` - Overrides the flag name; `flag:"-"` defines no flag
 //
 // # Quick Start
 //
@@ -108,6 +122,265 @@
 //		log.Fatal(err)
 //	}
 //
+// LoadWithDotenv sets the variables of the files in the process environment
+// unless already set. DotenvSource reads them without doing so; see Sources.
+//
+// # Lists
+//
+// Slices and arrays are split on "," or on the field's `sep` tag. Double quotes
+// keep separators inside a value, and a value quoted as a whole is unquoted
+// with "" standing for a quote. A backslash escapes the separator, a quote or
+// itself; other backslashes are kept, so regular expressions need no escaping.
+// In nested lists quotes and escapes apply to the inner elements, where a
+// backslash escapes either separator, so a\,b;c is [][]string{{"a,b"}, {"c"}}.
+//
+//	type Config struct {
+//		Admins   []mail.Address `env:"ADMINS"`             // "Doe, John" <j@x>, ops@x
+//		Patterns []string       `env:"PATTERNS" sep:";"`   // ^a,b$;\d+
+//		Rule     []*vm.Program  `env:"RULE" split:"false"` // max(a, b) > 10
+//		Matrix   [][]int        `env:"MATRIX"`             // 1,2;3,4
+//	}
+//
+// # Encoded Values
+//
+// A `format` tag decodes one JSON or YAML value into a field of any type,
+// which suits structured settings better than many variables:
+//
+//	type Route struct {
+//		Prefix  string `json:"prefix"`
+//		Backend string `json:"backend"`
+//		Token   string `json:"token" secret:"token"`
+//	}
+//
+//	type Config struct {
+//		Routes []Route `env:"ROUTES" format:"json"` // [{"prefix":"/api","backend":"http://api"}]
+//	}
+//
+// JSON errors report the byte offset of the problem. Fields of the decoded
+// value that carry a `secret` tag are still masked by PrettyString.
+// Configuration files must give such a field as a string too; a mapping or
+// sequence in its place is a parse error.
+//
+// # Secret Files
+//
+// Docker secrets and many Helm charts pass a file path instead of a value,
+// like API_KEY_FILE=/run/secrets/api_key. Fields tagged `file:"true"`, or
+// every field with the WithFileIndirection Loader option, are read from that
+// file when API_KEY itself is not set. One trailing newline is trimmed.
+// WithFileSuffix changes the "_FILE" suffix, and WithStrictFilePerms rejects
+// files readable by group or others. Errors name the file but never its contents.
+//
+// # Prefixes
+//
+// A `prefix` tag on a nested struct field lets one struct type be reused:
+//
+//	type PostgresConfig struct {
+//		Host string `env:"HOST" default:"localhost"`
+//		Port int    `env:"PORT" default:"5432"`
+//	}
+//
+//	type Config struct {
+//		Primary PostgresConfig `prefix:"PRIMARY_DB_"` // PRIMARY_DB_HOST, PRIMARY_DB_PORT
+//		Replica PostgresConfig `prefix:"REPLICA_DB_"` // REPLICA_DB_HOST, REPLICA_DB_PORT
+//	}
+//
+// LoadWithPrefix and the WithPrefix Loader option add a prefix to every variable.
+// With WithAutoEnv, untagged fields are named after their path in
+// SCREAMING_SNAKE_CASE, so DB.MaxConns is read from DB_MAX_CONNS. Tagged
+// fields keep their names, behind `prefix` tags only.
+//
+// # Slices of Structs
+//
+// A []Struct or []*Struct field is filled from indexed variables. Element i reads
+// its fields behind "<NAME>_<i>_", where NAME is the field's `prefix` tag (used
+// verbatim) or its variable name:
+//
+//	type Upstream struct {
+//		Host string `env:"HOST" required:"true"`
+//		Port int    `env:"PORT" default:"80"`
+//	}
+//
+//	type Config struct {
+//		Upstreams []Upstream `env:"UPSTREAMS" maxlen:"16"` // UPSTREAMS_0_HOST, UPSTREAMS_1_HOST, ...
+//	}
+//
+// Elements must be numbered from 0 without gaps. `default` and `required` tags
+// apply within each element that is present.
+//
+// # Maps of Structs
+//
+// A map[K]Struct or map[K]*Struct field holds named instances discovered from
+// the keys of sources that can list them (see KeyLister). A key of the form
+// "<NAME>_<INSTANCE>_<FIELD>" creates the instance and sets its field:
+//
+//	type Config struct {
+//		Databases map[string]DB `prefix:"DB_"` // DB_PRIMARY_HOST, DB_ANALYTICS_HOST, ...
+//	}
+//
+// Instance names are parsed into the map's key type. Every discovered instance
+// is loaded from all sources with its defaults applied.
+//
+// # Sources
+//
+// Load reads the process environment. A Loader reads from any ordered list of
+// sources instead, the first source that has a key taking precedence:
+//
+//	dotenv, err := gonfig.DotenvSource(".env")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), dotenv))
+//
+//	var cfg Config
+//	if err := l.Load(&cfg); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Built-in sources are EnvSource (process environment), MapSource (in-memory map),
+// EnvironSource (a []string in os.Environ format), DotenvSource (.env files) and
+// DirSource (one file per key, as Kubernetes mounts ConfigMaps and Secrets).
+// Any type implementing Source can be added to the list.
+//
+// `secret` fields are first looked up in the systemd credentials directory
+// ($CREDENTIALS_DIRECTORY, see CredentialsSource) and then in the regular
+// sources; WithSecretSources changes this. Loader.Settings reports where each
+// value came from in FieldSetting.Source, e.g. "env PORT" or "credential db-password".
+//
+// # Configuration Files
+//
+// YAMLFileSource reads a YAML file whose nested mappings correspond to nested
+// structs. Fields are named by their `yaml` tag or, without one, by their Go
+// name matched ignoring case, '_' and '-'; see PathSource. Layered below
+// EnvSource, environment variables override the file and the file overrides
+// `default` tags:
+//
+//	file, err := gonfig.YAMLFileSource("config.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), file))
+//
+// Values from a file go through the same parsers as environment variables and
+// parse errors carry the file, line and column, like "config.yaml:3:9".
+//
+// JSONFileSource does the same for JSON, with names from `json` tags. Its
+// errors locate values by JSON pointer, like "config.json#/db/port". Wrap
+// either source with Strict to fail with ErrUnknownKey on keys that no field
+// reads:
+//
+//	file, err := gonfig.JSONFileSource("config.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), gonfig.Strict(file)))
+//
+// TOMLFileSource reads TOML v1.0 without extra dependencies, with names from
+// `toml` tags. Tables and inline tables map onto nested structs, arrays of
+// tables onto slices of structs, and datetimes decode into time.Time. Errors
+// carry the line and column, as for YAML.
+//
+// INIFileSource maps [section] headers onto nested structs named by an `ini`
+// tag or by their `prefix` tag, so [db] fills a field tagged `prefix:"DB_"`.
+// It understands ';' and '#' comments, quoted values and lines continued
+// with a trailing backslash. A repeated key collects its values into a slice:
+//
+//	[upstream]
+//	host = a.internal
+//	host = b.internal   ; Hosts []string `ini:"host"`
+//
+// Each file source has a variant reading from an fs.FS, such as an embed.FS
+// shipped with the binary or an fstest.MapFS in tests: YAMLFSSource,
+// JSONFSSource, TOMLFSSource, INIFSSource and DotenvFSSource. DirSource takes
+// an FS field, and WithFileFS resolves <KEY>_FILE paths in an fs.FS. Embedded
+// defaults go last, below the files on disk and the environment:
+//
+//	//go:embed defaults.yaml
+//	var defaults embed.FS
+//
+//	base, err := gonfig.YAMLFSSource(defaults, "defaults.yaml")
+//	...
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), disk, base))
+//
+// # Command-Line Flags
+//
+// Loader.RegisterFlags defines a flag for every field, named after its path
+// (DB.Host becomes --db-host), with usage text from the `desc` tag and the
+// default from the `default` tag. Flags given on the command line take
+// precedence over every source, and their values are checked by the same
+// parsers when the flag set is parsed. Secret fields get flags only with
+// WithSecretFlags, since command lines show up in ps output:
+//
+//	l := gonfig.NewLoader()
+//	if err := l.RegisterFlags(flag.CommandLine, &cfg); err != nil {
+//		log.Fatal(err)
+//	}
+//	flag.Parse()
+//	err := l.Load(&cfg)
+//
+// # Remote Sources
+//
+// HTTPSource serves a JSON document fetched over HTTP, mapped onto the config
+// struct like JSONFileSource. Its HTTPConfig is loaded with gonfig too, so the
+// bearer token and headers it sends come from secrets. Sources that can
+// change implement Refresher, and Watch polls one, calling back when its
+// values change; HTTPSource sends If-None-Match, so an unchanged document
+// costs a 304. A load reads one snapshot of each such source (see
+// Snapshotter), so a refresh halfway through never mixes two versions:
+//
+//	var remote gonfig.HTTPConfig // CONFIG_URL, CONFIG_TOKEN, ...
+//	if err := gonfig.NewLoader(gonfig.WithPrefix("CONFIG_")).Load(&remote); err != nil {
+//		log.Fatal(err)
+//	}
+//	src, err := gonfig.NewHTTPSource(ctx, remote)
+//	...
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), src))
+//	go gonfig.Watch(ctx, src, 30*time.Second, func(err error) { ... })
+//
+// VaultSource reads `secret` fields from a Vault-compatible server, located
+// by a `vault` tag naming the secret path and key. It authenticates with a
+// token or AppRole, renews the token and leases on Refresh, and reports KV
+// v2 secret versions in Loader.Settings:
+//
+//	type Config struct {
+//		APIKey string `secret:"API_KEY" vault:"kv/data/app#api_key"`
+//	}
+//
+//	var vc gonfig.VaultConfig // VAULT_ADDR, VAULT_TOKEN or VAULT_ROLE_ID, ...
+//	if err := gonfig.NewLoader(gonfig.WithPrefix("VAULT_")).Load(&vc); err != nil {
+//		log.Fatal(err)
+//	}
+//	vault, err := gonfig.NewVaultSource(ctx, vc)
+//	...
+//	l := gonfig.NewLoader(gonfig.WithSecretSources(vault, gonfig.CredentialsSource()))
+//
+// ConsulSource maps the keys under a Consul KV folder onto the config struct
+// by their slash-separated path, so app/prod/db/host fills DB.Host. Its
+// Refresh is a blocking query, so Watch with an interval of zero hears of
+// changes without polling; when the agent is unreachable the last values stay:
+//
+//	var cc gonfig.ConsulConfig // CONSUL_HTTP_ADDR, CONSUL_KV_PREFIX, ...
+//	if err := gonfig.NewLoader(gonfig.WithPrefix("CONSUL_")).Load(&cc); err != nil {
+//		log.Fatal(err)
+//	}
+//	consul, err := gonfig.NewConsulSource(ctx, cc)
+//	...
+//	go gonfig.Watch(ctx, consul, 0, reload)
+//
+// SSMSource maps the parameters under an AWS Systems Manager Parameter Store
+// path onto the config struct, so /app/prod/db/password fills DB.Password.
+// It pages through GetParametersByPath with NextToken, decrypts SecureString
+// values and signs its requests with the configured credentials. Set
+// SSMConfig.Endpoint to point it at a private endpoint or a local fake.
+//
+// SQLSource serves settings kept in a database table with key, value and
+// updated_at columns. Its query takes the latest updated_at seen, so each
+// Refresh reads only the rows changed since:
+//
+//	settings, err := gonfig.NewSQLSource(ctx, db,
+//		"SELECT key, value, updated_at FROM settings WHERE updated_at >= $1")
+//	...
+//	go gonfig.Watch(ctx, settings, time.Minute, reload)
+//
 // # API Reference
 //
 // The package provides three main functions:
@@ -115,6 +388,7 @@
 //	func Load[T any](cfg T) (T, error)                    // Load from environment variables only
 //	func LoadWithDotenv[T any](cfg T, paths ...string) (T, error) // Load with .env file support
 //	func PrettyString(v any) string                       // Format config with masked secrets
+//	func NewLoader(opts ...Option) *Loader                // Load from an ordered list of sources
 //
 // # Error Handling
 //
@@ -125,4 +399,16 @@
 //   - Malformed PEM keys or other specialized formats
 //
 // All errors include context about the field name and expected format to aid in debugging.
+//
+// Loading does not stop at the first bad field. The returned error is a *LoadError
+// holding one FieldError per failure, and errors.Is matches the sentinels
+// ErrRequired, ErrParse, ErrUnsupportedType, ErrSource and ErrUnknownKey:
+//
+//	cfg, err := gonfig.Load(Config{})
+//	var loadErr *gonfig.LoadError
+//	if errors.As(err, &loadErr) {
+//		for _, fe := range loadErr.Errors {
+//			log.Printf("%s (%s): %v", fe.Path, fe.EnvVar, fe.Err)
+//		}
+//	}
 package gonfig
@@ -0,0 +1,80 @@
+package gonfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors describing why a field failed to load.
+// Use errors.Is on the error returned by Load to test for them.
+var (
+	// ErrRequired reports a required field with no value and no default.
+	ErrRequired = errors.New("required value missing")
+	// ErrParse reports a value that could not be converted to the field's type.
+	ErrParse = errors.New("invalid value")
+	// ErrUnsupportedType reports a field whose type gonfig cannot load.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrSource reports a source that failed while looking up a value.
+	ErrSource = errors.New("source lookup failed")
+	// ErrUnknownKey reports a key in a strict source that no field reads.
+	ErrUnknownKey = errors.New("unknown key")
+)
+
+// FieldError describes a single field that failed to load.
+type FieldError struct {
+	Path   string // Dot-separated field path (e.g., "DB.Port"), or the key's path for ErrUnknownKey
+	EnvVar string // Environment variable (or source key) that was consulted
+	Kind   error  // One of ErrRequired, ErrParse, ErrUnsupportedType, ErrSource, ErrUnknownKey
+	Err    error  // Underlying error, nil for ErrRequired
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Kind == ErrRequired {
+		return fmt.Sprintf("required env %q missing", e.EnvVar)
+	}
+	if e.Kind == ErrUnknownKey {
+		// Err already locates the key; there is no field to name
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("field %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the error kind and the underlying error, so that errors.Is
+// matches both the sentinel and whatever the parser or source returned.
+func (e *FieldError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// LoadError is returned by Load when one or more fields fail to load.
+// Load keeps walking the struct after a failure, so Errors lists every
+// missing or invalid field, in struct order.
+type LoadError struct {
+	Errors []FieldError
+}
+
+// Error implements the error interface. A single failure is reported as is;
+// several are counted and joined.
+func (e *LoadError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	return fmt.Sprintf("%d config errors: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns every field error, so errors.Is and errors.As see all of them.
+func (e *LoadError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = &e.Errors[i]
+	}
+	return errs
+}
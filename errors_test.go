@@ -0,0 +1,102 @@
+package gonfig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCollectsAllFieldErrors(t *testing.T) {
+	type Config struct {
+		Host  string `env:"ERR_HOST" required:"true"`
+		Port  int    `env:"ERR_PORT"`
+		Token string `secret:"ERR_TOKEN" required:"true"`
+		DB    struct {
+			Name    string  `env:"ERR_DB_NAME" required:"true"`
+			Timeout float64 `env:"ERR_DB_TIMEOUT"`
+		}
+		Valid string `env:"ERR_VALID"`
+	}
+
+	l := NewLoader(WithSources(MapSource{
+		"ERR_PORT":       "not-a-number",
+		"ERR_DB_TIMEOUT": "soon",
+		"ERR_VALID":      "ok",
+	}))
+
+	var cfg Config
+	err := l.Load(&cfg)
+	require.Error(t, err)
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	require.Len(t, loadErr.Errors, 5)
+
+	want := []struct {
+		path, env string
+		kind      error
+	}{
+		{"Host", "ERR_HOST", ErrRequired},
+		{"Port", "ERR_PORT", ErrParse},
+		{"Token", "ERR_TOKEN", ErrRequired},
+		{"DB.Name", "ERR_DB_NAME", ErrRequired},
+		{"DB.Timeout", "ERR_DB_TIMEOUT", ErrParse},
+	}
+	for i, w := range want {
+		fe := loadErr.Errors[i]
+		assert.Equal(t, w.path, fe.Path)
+		assert.Equal(t, w.env, fe.EnvVar)
+		assert.Equal(t, w.kind, fe.Kind)
+	}
+
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.NotErrorIs(t, err, ErrUnsupportedType)
+	assert.Contains(t, err.Error(), "5 config errors")
+	assert.Contains(t, err.Error(), `required env "ERR_DB_NAME" missing`)
+	assert.Contains(t, err.Error(), "field DB.Timeout")
+
+	// Fields that loaded fine are still populated
+	assert.Equal(t, "ok", cfg.Valid)
+}
+
+func TestLoadSingleErrorMessage(t *testing.T) {
+	type Config struct {
+		Port int `env:"ERR_SINGLE_PORT" default:"eighty"`
+	}
+
+	_, err := Load(Config{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrParse)
+	assert.Equal(t, `field Port: strconv.ParseInt: parsing "eighty": invalid syntax`, err.Error())
+
+	var fe *FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "ERR_SINGLE_PORT", fe.EnvVar)
+}
+
+func TestLoadUnsupportedTypeError(t *testing.T) {
+	type Config struct {
+		Ch chan int `env:"ERR_CHAN" default:"1"`
+	}
+
+	_, err := Load(Config{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	assert.NotErrorIs(t, err, ErrParse)
+}
+
+func TestLoadSourceErrorKind(t *testing.T) {
+	type Config struct {
+		Name string `env:"ERR_SOURCE_NAME"`
+	}
+
+	boom := errors.New("connection refused")
+	var cfg Config
+	err := NewLoader(WithSources(failingSource{err: boom})).Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrSource)
+	assert.ErrorIs(t, err, boom)
+}
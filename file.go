@@ -0,0 +1,91 @@
+package gonfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// defaultFileSuffix names the variable holding the path of a value file:
+// API_KEY_FILE for API_KEY.
+const defaultFileSuffix = "_FILE"
+
+// fileConfig controls reading values from files named by <KEY>_FILE variables.
+type fileConfig struct {
+	all         bool   // every field, not only those tagged `file:"true"`
+	suffix      string // appended to a key to name its file variable
+	strictPerms bool   // reject files readable by group or others
+	fsys        fs.FS  // file system holding the files; nil for the OS
+}
+
+// enabled reports whether the field may be read from a file.
+func (c fileConfig) enabled(sf reflect.StructField) bool {
+	return c.all || sf.Tag.Get("file") == "true"
+}
+
+// lookupFile reads the value of key from the file named by key's file
+// variable. It returns the file variable, the file path and its contents.
+func (d *decoder) lookupFile(key string) (fileKey, path, contents string, ok bool, err error) {
+	fileKey = key + d.files.suffix
+	path, ok, err = d.lookup(fileKey)
+	if err != nil || !ok || path == "" {
+		return fileKey, "", "", false, err
+	}
+	fsys := d.files.fsys
+	if fsys == nil {
+		fsys = osFS{}
+	} else {
+		// fs.FS paths are unrooted, so /run/secrets/x names run/secrets/x
+		path = strings.TrimPrefix(path, "/")
+	}
+	contents, err = readValueFile(fsys, path, d.files.strictPerms)
+	if err != nil {
+		return fileKey, path, "", false, err
+	}
+	return fileKey, path, contents, true, nil
+}
+
+// readValueFile returns the contents of the file at path in fsys without one
+// trailing newline, as left by most editors and by `echo secret > file`.
+// Errors name the path but never include the contents.
+func readValueFile(fsys fs.FS, path string, strictPerms bool) (string, error) {
+	if strictPerms {
+		info, err := fs.Stat(fsys, path)
+		if err != nil {
+			return "", err
+		}
+		if perm := info.Mode().Perm(); perm&0o044 != 0 {
+			return "", fmt.Errorf("%s is readable by group or others (mode %04o)", path, perm)
+		}
+	}
+
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", err
+	}
+	s := string(b)
+	if trimmed, ok := strings.CutSuffix(s, "\n"); ok {
+		s = strings.TrimSuffix(trimmed, "\r")
+	}
+	return s, nil
+}
+
+// osFS is the operating system's file system. Unlike os.DirFS it opens names
+// as given, so absolute and relative paths keep their usual meaning.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// fileParseError replaces a parse error for a value read from path, which
+// would quote the value, with one that names only the file.
+func fileParseError(path string, t reflect.Type, err error) error {
+	if errors.Is(err, ErrUnsupportedType) {
+		return err
+	}
+	return fmt.Errorf("contents of %s are not a valid %s", path, t)
+}
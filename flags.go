@@ -0,0 +1,146 @@
+package gonfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RegisterFlags defines a flag on fs for every leaf field of config, as
+// listed by Settings, and makes the flags given on the command line the
+// Loader's highest-precedence source.
+//
+// Flags are named after the field path in kebab case, so DB.Host becomes
+// --db-host and Upstreams[0].Host becomes --upstreams-0-host. A `flag` tag
+// overrides the name and `flag:"-"` leaves the field out. The `desc` tag
+// provides the usage text and the `default` tag the default shown with it.
+// Fields tagged `secret` get no flag unless the Loader has WithSecretFlags.
+//
+// Values are checked with the Loader's parsers when the flag is parsed, so
+// fs.Parse reports a bad --port like any other flag error. Flags that are not
+// given leave the field to the other sources and its default.
+//
+// Call RegisterFlags before fs.Parse and before loading, and not concurrently
+// with Load:
+//
+//	l := gonfig.NewLoader()
+//	if err := l.RegisterFlags(flag.CommandLine, &cfg); err != nil {
+//		log.Fatal(err)
+//	}
+//	flag.Parse()
+//	if err := l.Load(&cfg); err != nil {
+//		log.Fatal(err)
+//	}
+func (l *Loader) RegisterFlags(fs *flag.FlagSet, config any) error {
+	if rv := reflect.ValueOf(config); rv.Kind() != reflect.Struct && (rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct) {
+		return fmt.Errorf("config must be a struct or a pointer to struct, got %T", config)
+	}
+
+	// Check every flag before defining any, so a failure leaves fs untouched
+	type planned struct {
+		name string
+		s    FieldSetting
+	}
+	var flags []planned
+	paths := make(map[string]string) // flag name -> field path
+	for _, s := range l.Settings(config) {
+		if s.Secret && !l.secretFlags {
+			continue
+		}
+		name := s.Tags["flag"]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = flagName(s.Path)
+		}
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flag --%s for %s is already defined", name, s.Path)
+		}
+		if path, ok := paths[name]; ok {
+			return fmt.Errorf("flag --%s for %s is already defined for %s", name, s.Path, path)
+		}
+		paths[name] = s.Path
+		flags = append(flags, planned{name, s})
+	}
+
+	src := &flagSource{fs: fs, names: make(map[string]string)}
+	for _, f := range flags {
+		fs.Var(&flagValue{loader: l, field: f.s.field, def: f.s.Default}, f.name, f.s.Tags["desc"])
+		src.names[f.s.EnvVar] = f.name
+	}
+
+	l.sources = append([]Source{src}, l.sources...)
+	l.secretSources = append([]Source{src}, l.secretSources...)
+	return nil
+}
+
+// flagName turns a field path like "DB.MaxConns" or "Upstreams[0].Host"
+// into a flag name like "db-max-conns" or "upstreams-0-host".
+func flagName(path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ToLower(screamingSnake(p)), "_", "-")
+	}
+	return strings.Join(parts, "-")
+}
+
+// flagValue is a flag.Value holding the raw text of one field's flag.
+type flagValue struct {
+	loader *Loader
+	field  reflect.StructField
+	def    string // `default` tag, shown in usage
+	raw    string
+	set    bool
+}
+
+// String returns the value given on the command line or else the default.
+func (v *flagValue) String() string {
+	if v.set {
+		return v.raw
+	}
+	return v.def
+}
+
+// Set checks raw with the Loader's parsers and keeps it for the next load.
+func (v *flagValue) Set(raw string) error {
+	d := &decoder{reg: v.loader.parsers.snapshot()}
+	if _, err := d.decodeValue(raw, v.field.Type, v.field.Tag); err != nil {
+		return err
+	}
+	v.raw, v.set = raw, true
+	return nil
+}
+
+// IsBoolFlag lets boolean fields be set with a bare --flag.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.field.Type.Kind() == reflect.Bool
+}
+
+// flagSource serves the flags set on the command line, keyed like
+// environment variables.
+type flagSource struct {
+	fs    *flag.FlagSet
+	names map[string]string // key -> flag name
+}
+
+// Lookup implements Source.
+func (s *flagSource) Lookup(key string) (string, bool, error) {
+	name, ok := s.names[key]
+	if !ok {
+		return "", false, nil
+	}
+	v := s.fs.Lookup(name).Value.(*flagValue)
+	if !v.set {
+		return "", false, nil
+	}
+	return v.raw, true, nil
+}
+
+// Describe implements Describer.
+func (s *flagSource) Describe(key string) string {
+	return "flag --" + s.names[key]
+}
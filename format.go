@@ -0,0 +1,49 @@
+package gonfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hasFormat reports whether the field is decoded as a whole from an encoded
+// value through its `format` tag, instead of field by field.
+func hasFormat(sf reflect.StructField) bool {
+	return sf.Tag.Get("format") != ""
+}
+
+// decodeFormat decodes raw, encoded as format ("json" or "yaml"), into a new
+// value of type t.
+func decodeFormat(raw string, t reflect.Type, format string) (reflect.Value, error) {
+	ptr := reflect.New(t)
+
+	switch format {
+	case "json":
+		if err := json.Unmarshal([]byte(raw), ptr.Interface()); err != nil {
+			return reflect.Value{}, jsonError(err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal([]byte(raw), ptr.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid YAML: %w", err)
+		}
+	default:
+		return reflect.Value{}, fmt.Errorf("%w: format %q", ErrUnsupportedType, format)
+	}
+	return ptr.Elem(), nil
+}
+
+// jsonError adds the byte offset of a JSON syntax or type error to its message.
+func jsonError(err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid JSON at offset %d: %w", syntaxErr.Offset, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("invalid JSON at offset %d: %w", typeErr.Offset, err)
+	}
+	return fmt.Errorf("invalid JSON: %w", err)
+}
@@ -0,0 +1,164 @@
+package gonfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// HTTPConfig configures an HTTPSource. It is itself loaded with gonfig, so
+// the credentials it carries come from secrets like any other:
+//
+//	var remote gonfig.HTTPConfig
+//	if err := gonfig.NewLoader(gonfig.WithPrefix("CONFIG_")).Load(&remote); err != nil {
+//		log.Fatal(err)
+//	}
+type HTTPConfig struct {
+	// URL of the JSON document.
+	URL string `env:"URL" required:"true"`
+
+	// Timeout bounds each request, including reading the body.
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+
+	// Token is sent as "Authorization: Bearer <token>" when set.
+	Token string `secret:"TOKEN"`
+
+	// Headers are added to every request, e.g. "X-Api-Key=k1,X-Tenant=acme".
+	Headers map[string]string `secret:"HEADERS"`
+
+	TLSConfig
+}
+
+// TLSConfig holds the TLS settings shared by the remote sources. Embedded
+// without a prefix, its variables sit beside those of the enclosing config,
+// like CONFIG_CA_FILE.
+type TLSConfig struct {
+	// CAFile holds PEM certificates trusted instead of the system roots.
+	CAFile string `env:"CA_FILE"`
+
+	// CertFile and KeyFile hold a PEM client certificate and key for mutual TLS.
+	CertFile string `env:"CERT_FILE"`
+	KeyFile  string `env:"KEY_FILE"`
+
+	// InsecureSkipVerify disables server certificate checks. Use it only
+	// against test servers.
+	InsecureSkipVerify bool `env:"INSECURE_SKIP_VERIFY"`
+}
+
+// client returns an HTTP client with timeout and the TLS settings of c.
+func (c TLSConfig) client(timeout time.Duration) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificates", c.CAFile)
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// HTTPSource is a Source backed by a JSON document fetched over HTTP. The
+// document maps onto the config struct as with JSONFileSource, and Keys
+// lists it flattened to dotted keys like "db.host".
+//
+// Refresh fetches the document again with If-None-Match, so an unchanged
+// document costs the server a 304 Not Modified; use Watch to poll.
+type HTTPSource struct {
+	cfg    HTTPConfig
+	client *http.Client
+
+	liveTree
+
+	mu   sync.Mutex // guards etag and body
+	etag string
+	body []byte
+}
+
+// NewHTTPSource fetches the document at cfg.URL and returns a source serving it.
+func NewHTTPSource(ctx context.Context, cfg HTTPConfig) (*HTTPSource, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("http source: URL is required")
+	}
+	client, err := cfg.client(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("http source: %w", err)
+	}
+	s := &HTTPSource{cfg: cfg, client: client}
+	if _, err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh implements Refresher.
+func (s *HTTPSource) Refresh(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+	s.mu.Lock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	s.mu.Unlock()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("GET %s: %s", s.cfg.URL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("GET %s: %w", s.cfg.URL, err)
+	}
+	src, err := parseJSONSource(s.cfg.URL, body)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := !bytes.Equal(body, s.body)
+	s.etag, s.body = resp.Header.Get("ETag"), body
+	s.set(src.(*treeSource))
+	return changed, nil
+}
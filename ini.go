@@ -0,0 +1,204 @@
+package gonfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// INIFileSource returns a Source backed by the INI file at path.
+//
+// A [section] header maps onto the nested struct field named by its `ini`
+// tag or, without one, by its `prefix` tag less the trailing '_', so [db]
+// fills a field tagged `prefix:"DB_"`. Dotted headers like [cache.redis]
+// reach nested structs further down. Keys before the first header belong to
+// the top-level struct.
+//
+// Entries are written key = value or key: value. Lines starting with ';' or
+// '#' are comments, as is the rest of a line after a ';' or '#' that follows
+// whitespace. Values may be quoted: "..." understands \", \\, \n, \r and \t,
+// while '...' is taken literally. A line ending in a backslash continues on
+// the next line. A key repeated within a section accumulates into a slice;
+// scalar fields read its last value.
+func INIFileSource(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseINISource(path, data)
+}
+
+// INIFSSource is like INIFileSource but reads the file at path in fsys, such
+// as an embed.FS holding defaults shipped with the binary.
+func INIFSSource(fsys fs.FS, path string) (Source, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseINISource(path, data)
+}
+
+// INISource returns a Source backed by INI data, like INIFileSource.
+func INISource(data []byte) (Source, error) {
+	return parseINISource("ini", data)
+}
+
+// parseINISource parses data, naming locations after name.
+func parseINISource(name string, data []byte) (Source, error) {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	root := &node{kind: mappingNode, pos: name}
+	section := root
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+		for strings.HasSuffix(line, `\`) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t")
+		}
+		trimmed = strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", name, lineNo)
+
+		if trimmed[0] == '[' {
+			end := strings.IndexByte(trimmed, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unterminated section header", pos)
+			}
+			if rest := strings.TrimSpace(trimmed[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, fmt.Errorf("%s: unexpected %q after section header", pos, rest)
+			}
+			section = root
+			for _, part := range strings.Split(trimmed[1:end], ".") {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					return nil, fmt.Errorf("%s: empty section name", pos)
+				}
+				var child *node
+				if j := indexOf(section.keys, part); j >= 0 {
+					child = section.items[j]
+				}
+				if child == nil {
+					child = &node{kind: mappingNode, pos: pos}
+					section.keys = append(section.keys, part)
+					section.items = append(section.items, child)
+				} else if child.kind != mappingNode {
+					return nil, fmt.Errorf("%s: section %q is already a key", pos, part)
+				}
+				section = child
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("%s: expected key = value", pos)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if key == "" {
+			return nil, fmt.Errorf("%s: missing key", pos)
+		}
+		raw := line[sep+1:]
+		col := sep + 2 + len(raw) - len(strings.TrimLeft(raw, " \t"))
+		value, err := iniValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", pos, col, err)
+		}
+		if err := addINIValue(section, key, &node{kind: scalarNode, value: value, pos: fmt.Sprintf("%s:%d", pos, col)}); err != nil {
+			return nil, fmt.Errorf("%s: %w", pos, err)
+		}
+	}
+	return &treeSource{tag: "ini", root: root, prefixNames: true, repeatable: true}, nil
+}
+
+// addINIValue adds v under key in section, turning a repeated key into a list.
+func addINIValue(section *node, key string, v *node) error {
+	i := indexOf(section.keys, key)
+	if i < 0 {
+		section.keys = append(section.keys, key)
+		section.items = append(section.items, v)
+		return nil
+	}
+	switch prev := section.items[i]; prev.kind {
+	case mappingNode:
+		return fmt.Errorf("key %q is already a section", key)
+	case sequenceNode:
+		prev.items = append(prev.items, v)
+	default:
+		section.items[i] = &node{kind: sequenceNode, items: []*node{prev, v}, pos: prev.pos}
+	}
+	return nil
+}
+
+// iniValue unquotes v and strips a trailing comment.
+func iniValue(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+
+	var (
+		out  strings.Builder
+		rest string
+	)
+	switch v[0] {
+	case '\'':
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated quoted value")
+		}
+		out.WriteString(v[1 : end+1])
+		rest = v[end+2:]
+	case '"':
+		i := 1
+		for ; i < len(v) && v[i] != '"'; i++ {
+			if v[i] != '\\' || i+1 == len(v) {
+				out.WriteByte(v[i])
+				continue
+			}
+			i++
+			switch v[i] {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case '"', '\\':
+				out.WriteByte(v[i])
+			default:
+				return "", fmt.Errorf("invalid escape \\%c in quoted value", v[i])
+			}
+		}
+		if i == len(v) {
+			return "", errors.New("unterminated quoted value")
+		}
+		rest = v[i+1:]
+	default:
+		return stripINIComment(v), nil
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && rest[0] != ';' && rest[0] != '#' {
+		return "", fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	return out.String(), nil
+}
+
+// stripINIComment removes a comment, a ';' or '#' after whitespace, from the
+// end of an unquoted value.
+func stripINIComment(v string) string {
+	for i := 1; i < len(v); i++ {
+		if (v[i] == ';' || v[i] == '#') && (v[i-1] == ' ' || v[i-1] == '\t') {
+			return strings.TrimSpace(v[:i])
+		}
+	}
+	return v
+}
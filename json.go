@@ -0,0 +1,150 @@
+package gonfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// JSONFileSource returns a Source backed by the JSON file at path.
+// Objects correspond to nested structs and arrays to slices; see PathSource
+// for how fields are named. Field names come from `json` tags.
+//
+// Every value goes through the same parsers as environment variables, so
+// strings like "1Gi" or "5m" still become resource.Quantity or time.Duration.
+// Errors locate values by JSON pointer, as in "config.json#/db/port".
+// Wrap the source with Strict to reject keys that no field reads.
+func JSONFileSource(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONSource(path, data)
+}
+
+// JSONFSSource is like JSONFileSource but reads the file at path in fsys, such
+// as an embed.FS holding defaults shipped with the binary.
+func JSONFSSource(fsys fs.FS, path string) (Source, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONSource(path, data)
+}
+
+// JSONSource returns a Source backed by JSON data, like JSONFileSource.
+func JSONSource(data []byte) (Source, error) {
+	return parseJSONSource("json", data)
+}
+
+// parseJSONSource parses data, naming locations after name.
+func parseJSONSource(name string, data []byte) (Source, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if errors.Is(err, io.EOF) {
+		// An empty document has no keys
+		return &treeSource{tag: "json", root: &node{kind: mappingNode, pos: name + "#"}}, nil
+	}
+	var root *node
+	if err == nil {
+		root, err = jsonValueToNode(dec, tok, name, "")
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%s: invalid JSON at offset %d: %w", name, dec.InputOffset(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, jsonError(err))
+	}
+	if root.kind != mappingNode {
+		return nil, fmt.Errorf("%s: top level must be an object", name)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: invalid JSON at offset %d: data after top-level object", name, dec.InputOffset())
+	}
+	return &treeSource{tag: "json", root: root}, nil
+}
+
+// jsonToNode reads the next JSON value from dec. pointer is its JSON pointer.
+func jsonToNode(dec *json.Decoder, name, pointer string) (*node, error) {
+	tok, err := jsonToken(dec)
+	if err != nil {
+		return nil, err
+	}
+	return jsonValueToNode(dec, tok, name, pointer)
+}
+
+// jsonToken reads the next token of a value under way, where the end of the
+// input means it was cut off.
+func jsonToken(dec *json.Decoder) (json.Token, error) {
+	tok, err := dec.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// jsonValueToNode reads the JSON value starting with tok from dec.
+func jsonValueToNode(dec *json.Decoder, tok json.Token, name, pointer string) (*node, error) {
+	n := &node{pos: name + "#" + pointer}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			n.kind = sequenceNode
+			for i := 0; dec.More(); i++ {
+				c, err := jsonToNode(dec, name, fmt.Sprintf("%s/%d", pointer, i))
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, c)
+			}
+		} else {
+			n.kind = mappingNode
+			for dec.More() {
+				tok, err := jsonToken(dec)
+				if err != nil {
+					return nil, err
+				}
+				key := tok.(string)
+				childPointer := pointer + "/" + escapePointer(key)
+				if indexOf(n.keys, key) >= 0 {
+					return nil, fmt.Errorf("%s#%s: duplicate key %q", name, childPointer, key)
+				}
+				c, err := jsonToNode(dec, name, childPointer)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.items = append(n.items, c)
+			}
+		}
+		// Closing bracket or brace
+		if _, err := jsonToken(dec); err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind = scalarNode
+		n.value = v
+	case json.Number:
+		n.kind = scalarNode
+		n.value = v.String()
+	case bool:
+		n.kind = scalarNode
+		n.value = fmt.Sprint(v)
+	case nil:
+		n.kind = nullNode
+	}
+	return n, nil
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,362 @@
+package gonfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Loader loads configuration structs from an ordered list of sources.
+//
+// Sources are consulted in the order they were given and the first source
+// that has a key wins, so the first source has the highest precedence.
+// Default tags apply only when no source has the key.
+//
+// Example:
+//
+//	dotenv, _ := gonfig.DotenvSource(".env")
+//	l := gonfig.NewLoader(gonfig.WithSources(
+//	    gonfig.EnvSource(), // highest precedence
+//	    dotenv,
+//	    gonfig.MapSource{"PORT": "8080"}, // lowest precedence
+//	))
+//
+//	var cfg Config
+//	if err := l.Load(&cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Each Loader has its own parser registry, seeded with the package-level parsers
+// when the Loader is created. Parsers registered on one Loader are invisible to
+// other Loaders and to the package-level functions, so two libraries in one
+// binary can register different parsers for the same type.
+type Loader struct {
+	sources       []Source
+	secretSources []Source // consulted before sources for `secret` fields
+	parsers       *parserRegistry
+	prefix        string // prepended to every environment variable name
+	autoEnv       bool   // derive names of untagged fields from their path
+	files         fileConfig
+	secretFlags   bool // let RegisterFlags define flags for `secret` fields
+
+	mu      sync.Mutex
+	origins map[string]string // field path -> provenance, from the last load
+}
+
+// Option configures a Loader.
+type Option func(*Loader)
+
+// WithSources sets the sources a Loader reads from, highest precedence first.
+// It replaces the default, which is EnvSource alone.
+func WithSources(sources ...Source) Option {
+	return func(l *Loader) {
+		l.sources = append([]Source(nil), sources...)
+	}
+}
+
+// WithSecretSources sets the sources consulted first for `secret` fields,
+// before the regular sources. It replaces the default, CredentialsSource, so
+// WithSecretSources() without arguments reads secrets like any other field.
+func WithSecretSources(sources ...Source) Option {
+	return func(l *Loader) {
+		l.secretSources = append([]Source(nil), sources...)
+	}
+}
+
+// WithPrefix prepends prefix to every environment variable name the Loader
+// looks up, including those inside nested structs.
+func WithPrefix(prefix string) Option {
+	return func(l *Loader) {
+		l.prefix = prefix
+	}
+}
+
+// WithAutoEnv derives the names of untagged fields from their path in
+// SCREAMING_SNAKE_CASE instead of using the bare Go field name. Nested structs
+// without a `prefix` tag then contribute their own name, so DB.MaxConns is read
+// from DB_MAX_CONNS; embedded structs contribute nothing. Fields with an `env`
+// or `secret` tag keep their tag name behind WithPrefix and `prefix` tags only.
+func WithAutoEnv() Option {
+	return func(l *Loader) {
+		l.autoEnv = true
+	}
+}
+
+// WithFileIndirection lets every field be read from a file named by
+// <KEY>_FILE when <KEY> itself is not set, as Docker secrets and many Helm
+// charts do with API_KEY_FILE=/run/secrets/api_key. Without it only fields
+// tagged `file:"true"` are read this way. A value set directly always wins.
+func WithFileIndirection() Option {
+	return func(l *Loader) {
+		l.files.all = true
+	}
+}
+
+// WithFileSuffix changes the suffix naming file variables, "_FILE" by default.
+func WithFileSuffix(suffix string) Option {
+	return func(l *Loader) {
+		l.files.suffix = suffix
+	}
+}
+
+// WithFileFS resolves the paths named by <KEY>_FILE variables in fsys instead
+// of the operating system's file system. A leading '/' is dropped, since fs.FS
+// paths are relative to its root, so tests can serve API_KEY_FILE=/run/secrets/api_key
+// from an fstest.MapFS holding "run/secrets/api_key".
+func WithFileFS(fsys fs.FS) Option {
+	return func(l *Loader) {
+		l.files.fsys = fsys
+	}
+}
+
+// WithStrictFilePerms rejects value files that are readable by group or
+// others, so a secret left world-readable fails the load instead of being used.
+func WithStrictFilePerms() Option {
+	return func(l *Loader) {
+		l.files.strictPerms = true
+	}
+}
+
+// WithSecretFlags lets RegisterFlags define flags for `secret` fields too.
+// They are left out by default because command lines show up in ps output and
+// shell history.
+func WithSecretFlags() Option {
+	return func(l *Loader) {
+		l.secretFlags = true
+	}
+}
+
+// NewLoader creates a Loader. Without options it reads from the process
+// environment, exactly like Load.
+//
+// The Loader starts with a copy of the parsers registered so far, including the
+// built-in ones; see Loader.RegisterParser.
+func NewLoader(opts ...Option) *Loader {
+	return newLoader(newParserRegistry(defaultRegistry.snapshot()), opts...)
+}
+
+func newLoader(parsers *parserRegistry, opts ...Option) *Loader {
+	l := &Loader{
+		sources:       []Source{EnvSource()},
+		secretSources: []Source{CredentialsSource()},
+		parsers:       parsers,
+		files:         fileConfig{suffix: defaultFileSuffix},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// std is the Loader behind the package-level Load functions.
+// It shares the package-level registry, so RegisterParser affects it directly.
+var std = newLoader(defaultRegistry)
+
+// RegisterParser registers a parser for typ on this Loader only.
+// It takes precedence over any parser for typ inherited from the package level.
+// It is safe to call concurrently with Load.
+func (l *Loader) RegisterParser(typ reflect.Type, fn parserFunc) {
+	l.parsers.update(func(r *registry) { r.parsers[typ] = fn })
+}
+
+// RegisterParserFactory registers a parser factory on this Loader only.
+// Factories are consulted in registration order, after explicit parsers.
+func (l *Loader) RegisterParserFactory(factory parserFactory) {
+	l.parsers.update(func(r *registry) { r.factories = append(r.factories, factory) })
+}
+
+// Load populates the struct pointed to by config from the Loader's sources.
+// It accepts the same struct tags and field types as the package-level Load.
+func (l *Loader) Load(config any) error {
+	rv := reflect.ValueOf(config)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a non-nil pointer to struct, got %T", config)
+	}
+	return l.load(rv.Elem())
+}
+
+// load runs a single load into an addressable struct value.
+func (l *Loader) load(val reflect.Value) error {
+	// one snapshot of each source for the whole load
+	taken := make(map[Source]Source)
+	d := &decoder{
+		sources:       snapshotSources(l.sources, taken),
+		secretSources: snapshotSources(l.secretSources, taken),
+		reg:           l.parsers.snapshot(),
+		autoEnv:       l.autoEnv,
+		files:         l.files,
+		origins:       make(map[string]string),
+	}
+	d.loadStruct(val, "", plainPrefix(l.prefix), nil)
+	d.checkUnknown()
+
+	l.mu.Lock()
+	l.origins = d.origins
+	l.mu.Unlock()
+	return d.err()
+}
+
+// Settings works like the package-level Settings but names environment
+// variables the way this Loader does, honouring WithPrefix and WithAutoEnv.
+// It also reports in FieldSetting.Source where each field's value came from
+// during the Loader's last load.
+func (l *Loader) Settings(config any) []FieldSetting {
+	rv := reflect.ValueOf(config)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var settings []FieldSetting
+	collectSettings(l.parsers.snapshot(), rv, "", plainPrefix(l.prefix), l.autoEnv, &settings)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range settings {
+		settings[i].Source = l.origins[settings[i].Path]
+	}
+	return settings
+}
+
+// decoder carries the state of a single load.
+type decoder struct {
+	sources       []Source
+	secretSources []Source
+	reg           *registry // parsers snapshot, fixed for the whole load
+	autoEnv       bool
+	files         fileConfig
+	origins       map[string]string // field path -> provenance of its value
+	read          map[*node]bool    // values of strict sources read by some field
+	errs          []FieldError
+}
+
+// fail records a field that could not be loaded.
+func (d *decoder) fail(path, key string, kind, err error) {
+	d.errs = append(d.errs, FieldError{Path: path, EnvVar: key, Kind: kind, Err: err})
+}
+
+// failParse records a parser error, telling unsupported types apart from bad values.
+func (d *decoder) failParse(path, key string, err error) {
+	kind := ErrParse
+	if errors.Is(err, ErrUnsupportedType) {
+		kind = ErrUnsupportedType
+	}
+	d.fail(path, key, kind, err)
+}
+
+// err returns the collected failures as a *LoadError, or nil.
+func (d *decoder) err() error {
+	if len(d.errs) == 0 {
+		return nil
+	}
+	return &LoadError{Errors: d.errs}
+}
+
+// lookup returns the value of key from the highest-precedence source that has it.
+func (d *decoder) lookup(key string) (string, bool, error) {
+	v, _, ok, err := lookupSources(d.sources, key)
+	return v, ok, err
+}
+
+// found is a value located in one of the sources.
+type found struct {
+	src Source
+	key string // key or dot-separated path the source holds the value under
+	raw string // scalar value
+
+	// path locates a mapping or sequence in a PathSource, decoded by decodePath
+	path []string
+}
+
+// lookupField looks up a leaf field in the sources, by its key, by its path
+// segs in structured sources, or by the reference in its tag in RefSources.
+// Secret fields try the secret sources first.
+func (d *decoder) lookupField(sf reflect.StructField, key string, segs []pathSeg) (found, bool, error) {
+	sources := d.sources
+	if sf.Tag.Get("secret") != "" && len(d.secretSources) > 0 {
+		sources = append(append([]Source(nil), d.secretSources...), d.sources...)
+	}
+	d.markRead(sources, segs)
+
+	for _, src := range sources {
+		if rs, ok := src.(RefSource); ok {
+			ref := sf.Tag.Get(rs.RefTag())
+			if ref == "" {
+				continue
+			}
+			v, ok, err := rs.Lookup(ref)
+			if err != nil {
+				return found{}, false, fmt.Errorf("lookup %q: %w", ref, err)
+			}
+			if ok {
+				return found{src: src, key: ref, raw: v}, true, nil
+			}
+			continue
+		}
+
+		ps, ok := src.(PathSource)
+		if !ok {
+			v, ok, err := src.Lookup(key)
+			if err != nil {
+				return found{}, false, fmt.Errorf("lookup %q: %w", key, err)
+			}
+			if ok {
+				return found{src: src, key: key, raw: v}, true, nil
+			}
+			continue
+		}
+
+		path, ok := sourcePath(segs, ps)
+		if !ok {
+			continue
+		}
+		pathKey := strings.Join(path, ".")
+		// A mapping or sequence under a `format` field is reported by decodePath
+		if d.isCollection(sf) || hasFormat(sf) {
+			_, ok, err := ps.Children(path)
+			if err != nil {
+				return found{}, false, fmt.Errorf("lookup %q: %w", pathKey, err)
+			}
+			if ok {
+				return found{src: src, key: pathKey, path: path}, true, nil
+			}
+		}
+		v, ok, err := ps.LookupPath(path)
+		if err != nil {
+			return found{}, false, fmt.Errorf("lookup %q: %w", pathKey, err)
+		}
+		if ok {
+			return found{src: src, key: pathKey, raw: v}, true, nil
+		}
+	}
+	return found{}, false, nil
+}
+
+// isCollection reports whether a leaf field holds several values that a
+// structured source may give as a mapping or sequence.
+func (d *decoder) isCollection(sf reflect.StructField) bool {
+	switch sf.Type.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return !d.reg.isCustomParsedType(sf.Type) && !hasFormat(sf)
+	}
+	return false
+}
+
+// lookupSources returns the value of key from the first of sources that has it.
+func lookupSources(sources []Source, key string) (string, Source, bool, error) {
+	for _, src := range sources {
+		v, ok, err := src.Lookup(key)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("lookup %q: %w", key, err)
+		}
+		if ok {
+			return v, src, true, nil
+		}
+	}
+	return "", nil, false, nil
+}
@@ -0,0 +1,111 @@
+package gonfig
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loaderColor string
+
+type loaderRegistryConfig struct {
+	Color   loaderColor   `env:"LOADER_COLOR"`
+	Timeout time.Duration `env:"LOADER_TIMEOUT" default:"5s"`
+}
+
+func TestLoaderInheritsBuiltinParsers(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{"LOADER_COLOR": "red"}))
+
+	var cfg loaderRegistryConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, loaderColor("red"), cfg.Color)
+	assert.Equal(t, 5*time.Second, cfg.Timeout) // built-in time.Duration parser
+}
+
+func TestLoaderParsersAreIsolated(t *testing.T) {
+	src := MapSource{"LOADER_COLOR": "red"}
+	colorType := reflect.TypeOf(loaderColor(""))
+
+	upper := NewLoader(WithSources(src))
+	upper.RegisterParser(colorType, func(raw string) (any, error) {
+		return loaderColor(strings.ToUpper(raw)), nil
+	})
+
+	prefixed := NewLoader(WithSources(src))
+	prefixed.RegisterParser(colorType, func(raw string) (any, error) {
+		return loaderColor("color:" + raw), nil
+	})
+
+	var a, b, plain loaderRegistryConfig
+	require.NoError(t, upper.Load(&a))
+	require.NoError(t, prefixed.Load(&b))
+	require.NoError(t, NewLoader(WithSources(src)).Load(&plain))
+
+	assert.Equal(t, loaderColor("RED"), a.Color)
+	assert.Equal(t, loaderColor("color:red"), b.Color)
+	assert.Equal(t, loaderColor("red"), plain.Color)
+
+	// The package-level registry is untouched as well
+	_, registered := defaultRegistry.snapshot().parsers[colorType]
+	assert.False(t, registered)
+}
+
+func TestLoaderParserOverridesBuiltin(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{"LOADER_TIMEOUT": "fast"}))
+	l.RegisterParser(reflect.TypeOf(time.Duration(0)), func(raw string) (any, error) {
+		if raw == "fast" {
+			return time.Millisecond, nil
+		}
+		return time.ParseDuration(raw)
+	})
+
+	var cfg loaderRegistryConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, time.Millisecond, cfg.Timeout)
+}
+
+func TestLoaderParserFactory(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{"LOADER_COLOR": "blue"}))
+	l.RegisterParserFactory(func(t reflect.Type) parserFunc {
+		if t != reflect.TypeOf(loaderColor("")) {
+			return nil
+		}
+		return func(raw string) (any, error) {
+			return loaderColor("factory:" + raw), nil
+		}
+	})
+
+	var cfg loaderRegistryConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, loaderColor("factory:blue"), cfg.Color)
+}
+
+func TestLoaderConcurrentRegisterAndLoad(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{"LOADER_COLOR": "green"}))
+
+	type marker struct{ n int }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			typ := reflect.TypeOf(marker{})
+			l.RegisterParser(typ, func(string) (any, error) { return marker{n: i}, nil })
+			RegisterParser(typ, func(string) (any, error) { return marker{n: i}, nil })
+		}(i)
+		go func() {
+			defer wg.Done()
+			var cfg loaderRegistryConfig
+			assert.NoError(t, l.Load(&cfg))
+			_, err := Load(loaderRegistryConfig{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
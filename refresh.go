@@ -0,0 +1,151 @@
+package gonfig
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Refresher is implemented by remote sources whose values can change while
+// the program runs. Refresh fetches the latest values and reports whether
+// they changed. When it fails the source keeps serving its last known values.
+type Refresher interface {
+	Refresh(ctx context.Context) (changed bool, err error)
+}
+
+// Snapshotter is implemented by sources whose values can change while a load
+// runs, such as those implementing Refresher. Each load calls Snapshot once
+// per source and reads only the Source it returns, so one struct is never
+// filled from two versions of the values, like a new host with an old
+// password.
+type Snapshotter interface {
+	Snapshot() Source
+}
+
+// snapshotSources returns sources with each Snapshotter replaced by its
+// snapshot. taken holds the snapshots of pointer sources, so one listed both
+// as a source and a secret source gets a single snapshot.
+func snapshotSources(sources []Source, taken map[Source]Source) []Source {
+	out := make([]Source, len(sources))
+	for i, src := range sources {
+		sn, ok := src.(Snapshotter)
+		switch {
+		case !ok:
+			out[i] = src
+		case reflect.ValueOf(src).Kind() != reflect.Pointer:
+			// values such as DirSource may hold uncomparable fields
+			out[i] = sn.Snapshot()
+		default:
+			if _, seen := taken[src]; !seen {
+				taken[src] = sn.Snapshot()
+			}
+			out[i] = taken[src]
+		}
+	}
+	return out
+}
+
+// watchRetryDelay is the least time Watch waits after a failed refresh, so a
+// source whose Refresh returns at once does not spin while its server is down.
+const watchRetryDelay = time.Second
+
+// Watch refreshes r every interval until ctx is done and then returns
+// ctx.Err(). After a refresh that changed the values it calls notify with a
+// nil error, typically to load the configuration again; after a failed
+// refresh it calls notify with the error and keeps going.
+//
+// An interval of zero refreshes again right away, for sources whose Refresh
+// waits for a change on the server.
+//
+//	go gonfig.Watch(ctx, src, 30*time.Second, func(err error) {
+//		if err != nil {
+//			log.Printf("config refresh: %v", err)
+//			return
+//		}
+//		var cfg Config
+//		if err := l.Load(&cfg); err == nil {
+//			current.Store(&cfg)
+//		}
+//	})
+func Watch(ctx context.Context, r Refresher, interval time.Duration, notify func(error)) error {
+	wait := interval
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		changed, err := r.Refresh(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wait = interval
+		switch {
+		case err != nil:
+			wait = max(interval, watchRetryDelay)
+			notify(err)
+		case changed:
+			notify(nil)
+		}
+	}
+}
+
+// liveTree serves the values of a remote source, which swaps in a new tree
+// each time it fetches them.
+type liveTree struct {
+	mu   sync.RWMutex
+	tree *treeSource
+}
+
+// current returns the tree last fetched.
+func (t *liveTree) current() *treeSource {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tree
+}
+
+// set replaces the tree.
+func (t *liveTree) set(tree *treeSource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tree = tree
+}
+
+// Snapshot implements Snapshotter with the tree last fetched.
+func (t *liveTree) Snapshot() Source {
+	return t.current()
+}
+
+// Lookup implements Source for dotted keys like "db.host".
+func (t *liveTree) Lookup(key string) (string, bool, error) {
+	return t.current().Lookup(key)
+}
+
+// Keys implements KeyLister.
+func (t *liveTree) Keys() ([]string, error) {
+	return t.current().Keys()
+}
+
+// TagName implements PathSource.
+func (t *liveTree) TagName() string {
+	return t.current().TagName()
+}
+
+// LookupPath implements PathSource.
+func (t *liveTree) LookupPath(path []string) (string, bool, error) {
+	return t.current().LookupPath(path)
+}
+
+// Children implements PathSource.
+func (t *liveTree) Children(path []string) ([]string, bool, error) {
+	return t.current().Children(path)
+}
+
+// Describe implements Describer with the location of the value at key.
+func (t *liveTree) Describe(key string) string {
+	return t.current().Describe(key)
+}
@@ -0,0 +1,421 @@
+package gonfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Source supplies raw configuration values by key.
+//
+// Lookup reports whether the key is present. A missing key is not an error;
+// the error result is reserved for sources that could not be read at all.
+type Source interface {
+	Lookup(key string) (value string, ok bool, err error)
+}
+
+// KeyLister is implemented by sources that can enumerate their keys.
+// The loader uses it to discover instance names for map-of-struct fields,
+// skipping sources that don't implement it, and the element indexes of
+// slice-of-struct fields, probing those sources instead.
+type KeyLister interface {
+	Keys() ([]string, error)
+}
+
+// Describer is implemented by sources that can say where the value of a key
+// comes from, such as "env PORT" or "credential db-password".
+// Loader.Settings reports it as the provenance of each loaded field.
+type Describer interface {
+	Describe(key string) string
+}
+
+// RefSource is implemented by sources that locate each field by a reference
+// in a struct tag of their own, like `vault:"kv/data/app#api_key"`, rather
+// than by its environment variable name. The loader consults such a source
+// only for fields carrying the tag, passing the tag's value to Lookup.
+type RefSource interface {
+	Source
+
+	// RefTag names the struct tag holding references for this source.
+	RefTag() string
+}
+
+// describe returns where src keeps key, falling back to the source's type.
+func describe(src Source, key string) string {
+	if d, ok := src.(Describer); ok {
+		return d.Describe(key)
+	}
+	return fmt.Sprintf("%T %s", src, key)
+}
+
+// envSource reads from the process environment.
+type envSource struct{}
+
+func (envSource) Lookup(key string) (string, bool, error) {
+	v, ok := os.LookupEnv(key)
+	return v, ok, nil
+}
+
+func (envSource) Describe(key string) string {
+	return "env " + key
+}
+
+func (envSource) Keys() ([]string, error) {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if k, _, ok := strings.Cut(kv, "="); ok && k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// EnvSource returns a Source backed by the process environment (os.LookupEnv).
+// It is the only source used by Load.
+func EnvSource() Source {
+	return envSource{}
+}
+
+// MapSource is a Source backed by an in-memory map.
+// It is convenient for tests and for values computed at runtime.
+//
+// Example:
+//
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.MapSource{"PORT": "9090"}))
+type MapSource map[string]string
+
+// Lookup implements Source.
+func (m MapSource) Lookup(key string) (string, bool, error) {
+	v, ok := m[key]
+	return v, ok, nil
+}
+
+// Keys implements KeyLister.
+func (m MapSource) Keys() ([]string, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Describe implements Describer.
+func (m MapSource) Describe(key string) string {
+	return "map " + key
+}
+
+// EnvironSource returns a Source backed by a slice of "KEY=value" strings,
+// in the format of os.Environ and exec.Cmd.Env. Entries without '=' are ignored
+// and, as with exec.Cmd, the last entry for a duplicated key wins.
+func EnvironSource(environ []string) Source {
+	m := make(MapSource, len(environ))
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// DotenvSource returns a Source backed by one or more .env files.
+// Unlike godotenv.Load it does not modify the process environment.
+// When several files define the same key, the first file wins.
+func DotenvSource(paths ...string) (Source, error) {
+	return readDotenv(osFS{}, paths)
+}
+
+// DotenvFSSource is like DotenvSource but reads the files from fsys, such as
+// an embed.FS holding defaults shipped with the binary or an fstest.MapFS in
+// tests. Paths are slash-separated and relative to the root of fsys.
+func DotenvFSSource(fsys fs.FS, paths ...string) (Source, error) {
+	return readDotenv(fsys, paths)
+}
+
+// readDotenv reads the .env files at paths in fsys, the first file winning.
+func readDotenv(fsys fs.FS, paths []string) (Source, error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	src := dotenvSource{values: make(MapSource), files: make(map[string]string)}
+	for _, p := range paths {
+		f, err := fsys.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		vals, err := godotenv.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vals {
+			if _, exists := src.values[k]; !exists {
+				src.values[k] = v
+				src.files[k] = p
+			}
+		}
+	}
+	return src, nil
+}
+
+// dotenvSource holds the values read from .env files and the file of each key.
+type dotenvSource struct {
+	values MapSource
+	files  map[string]string
+}
+
+func (s dotenvSource) Lookup(key string) (string, bool, error) {
+	return s.values.Lookup(key)
+}
+
+func (s dotenvSource) Keys() ([]string, error) {
+	return s.values.Keys()
+}
+
+func (s dotenvSource) Describe(key string) string {
+	return "dotenv " + s.files[key]
+}
+
+// DirSource is a Source backed by directories of files, one key per file, as
+// Kubernetes mounts ConfigMaps and Secrets. The file name is the key and the
+// file contents, without one trailing newline, the value.
+//
+// Each load lists the directories once and reads the files it needs, so a
+// Loader sees the latest contents after Kubernetes swaps the `..data`
+// symlink. Entries whose name starts with ".." are Kubernetes bookkeeping and
+// are ignored, as are subdirectories.
+// A directory that does not exist holds no keys.
+//
+// Example:
+//
+//	l := gonfig.NewLoader(gonfig.WithSources(
+//	    gonfig.EnvSource(),
+//	    gonfig.DirSource{
+//	        Dirs:      []string{"/etc/secrets", "/etc/config"}, // secrets win
+//	        Normalize: gonfig.EnvStyle,                         // db.host -> DB_HOST
+//	    },
+//	))
+type DirSource struct {
+	// Dirs are searched in order and the first directory that has a key wins.
+	Dirs []string
+
+	// Normalize maps a file name to its key. Nil uses file names as they are.
+	Normalize func(name string) string
+
+	// FS holds Dirs, as slash-separated paths relative to its root. Nil uses
+	// the operating system's file system.
+	FS fs.FS
+}
+
+// fsys returns the file system holding s.Dirs.
+func (s DirSource) fsys() fs.FS {
+	if s.FS == nil {
+		return osFS{}
+	}
+	return s.FS
+}
+
+// join joins dir and name in the manner of s's file system.
+func (s DirSource) join(dir, name string) string {
+	if s.FS == nil {
+		return filepath.Join(dir, name)
+	}
+	return path.Join(dir, name)
+}
+
+// Lookup implements Source.
+func (s DirSource) Lookup(key string) (string, bool, error) {
+	return s.list().Lookup(key)
+}
+
+// Describe implements Describer, naming the file that holds key.
+func (s DirSource) Describe(key string) string {
+	return s.list().Describe(key)
+}
+
+// Keys implements KeyLister.
+func (s DirSource) Keys() ([]string, error) {
+	return s.list().Keys()
+}
+
+// Snapshot implements Snapshotter, listing the directories once so that a
+// load does not list them again for every key.
+func (s DirSource) Snapshot() Source {
+	return s.list()
+}
+
+// list lists s.Dirs, keeping for each key the file in the first directory
+// that has it.
+func (s DirSource) list() dirFiles {
+	l := dirFiles{fsys: s.fsys(), paths: make(map[string]string)}
+	for _, dir := range s.Dirs {
+		files, err := s.files(dir)
+		if err != nil {
+			l.err = err
+			return l
+		}
+		for k, path := range files {
+			if _, ok := l.paths[k]; !ok {
+				l.paths[k] = path
+			}
+		}
+	}
+	return l
+}
+
+// dirFiles is a listing of the directories of a DirSource. Its files are
+// read when looked up.
+type dirFiles struct {
+	fsys  fs.FS
+	paths map[string]string // key -> file
+	err   error             // listing failure, reported by Lookup and Keys
+}
+
+// Lookup implements Source.
+func (l dirFiles) Lookup(key string) (string, bool, error) {
+	if l.err != nil {
+		return "", false, l.err
+	}
+	path, ok := l.paths[key]
+	if !ok {
+		return "", false, nil
+	}
+	v, err := readValueFile(l.fsys, path, false)
+	if err != nil {
+		return "", false, err
+	}
+	return v, true, nil
+}
+
+// Keys implements KeyLister.
+func (l dirFiles) Keys() ([]string, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	keys := make([]string, 0, len(l.paths))
+	for k := range l.paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Describe implements Describer, naming the file that holds key.
+func (l dirFiles) Describe(key string) string {
+	if path, ok := l.paths[key]; ok {
+		return "file " + path
+	}
+	return "file " + key
+}
+
+// files maps the keys of the regular files in dir, following symlinks, to
+// their paths. When two names normalize to the same key, the first in
+// lexical order wins.
+func (s DirSource) files(dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(s.fsys(), dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		path := s.join(dir, name)
+		// Stat follows the symlinks Kubernetes creates for every key
+		info, err := fs.Stat(s.fsys(), path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		key := name
+		if s.Normalize != nil {
+			key = s.Normalize(name)
+		}
+		if _, exists := files[key]; !exists {
+			files[key] = path
+		}
+	}
+	return files, nil
+}
+
+// EnvStyle normalizes a file name to an environment variable style key:
+// upper case, with dots and dashes turned into underscores, so "db.host" and
+// "db-host" both become DB_HOST.
+func EnvStyle(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// credentialsEnv names the variable systemd sets to the directory holding a
+// service's credentials (LoadCredential=, SetCredential=).
+const credentialsEnv = "CREDENTIALS_DIRECTORY"
+
+// CredentialsSource returns a Source backed by the systemd credentials
+// directory named by $CREDENTIALS_DIRECTORY. Keys and credential names are
+// compared in EnvStyle, so a credential named db-password is found under
+// DB_PASSWORD. Without $CREDENTIALS_DIRECTORY the source has no keys.
+//
+// Loaders consult it before their other sources for `secret` fields, so
+// credentials never need to pass through the environment; see WithSecretSources.
+func CredentialsSource() Source {
+	return credentialsSource{}
+}
+
+type credentialsSource struct{}
+
+// dir returns the credentials directory as a DirSource, resolved on every
+// call so it follows the current environment.
+func (credentialsSource) dir() DirSource {
+	dir := os.Getenv(credentialsEnv)
+	if dir == "" {
+		return DirSource{}
+	}
+	return DirSource{Dirs: []string{dir}, Normalize: EnvStyle}
+}
+
+func (c credentialsSource) Lookup(key string) (string, bool, error) {
+	return credentialFiles{c.dir().list()}.Lookup(key)
+}
+
+func (c credentialsSource) Keys() ([]string, error) {
+	return c.dir().Keys()
+}
+
+func (c credentialsSource) Describe(key string) string {
+	return credentialFiles{c.dir().list()}.Describe(key)
+}
+
+// Snapshot implements Snapshotter, listing the directory once per load.
+func (c credentialsSource) Snapshot() Source {
+	return credentialFiles{c.dir().list()}
+}
+
+// credentialFiles is a listing of the credentials directory.
+type credentialFiles struct {
+	dirFiles
+}
+
+func (c credentialFiles) Lookup(key string) (string, bool, error) {
+	return c.dirFiles.Lookup(EnvStyle(key))
+}
+
+func (c credentialFiles) Describe(key string) string {
+	if path, ok := c.paths[EnvStyle(key)]; ok {
+		return "credential " + filepath.Base(path)
+	}
+	return "credential " + key
+}
@@ -0,0 +1,369 @@
+package gonfig
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sourceTestConfig struct {
+	Name  string `env:"SRC_NAME" default:"default-name"`
+	Port  int    `env:"SRC_PORT" default:"8080"`
+	Token string `secret:"SRC_TOKEN"`
+	DB    struct {
+		Host string `env:"SRC_DB_HOST" default:"localhost"`
+	}
+}
+
+func TestMapSource(t *testing.T) {
+	l := NewLoader(WithSources(MapSource{
+		"SRC_NAME":    "from-map",
+		"SRC_PORT":    "9090",
+		"SRC_DB_HOST": "db.internal",
+	}))
+
+	var cfg sourceTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	assert.Equal(t, "from-map", cfg.Name)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Empty(t, cfg.Token)
+}
+
+func TestLoaderIgnoresProcessEnvWithoutEnvSource(t *testing.T) {
+	t.Setenv("SRC_NAME", "from-env")
+
+	l := NewLoader(WithSources(MapSource{}))
+
+	var cfg sourceTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "default-name", cfg.Name)
+}
+
+func TestEnvironSource(t *testing.T) {
+	src := EnvironSource([]string{
+		"SRC_NAME=first",
+		"SRC_TOKEN=abc=def", // value may contain '='
+		"MALFORMED",
+		"SRC_NAME=second", // last entry wins
+	})
+
+	v, ok, err := src.Lookup("SRC_NAME")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "second", v)
+
+	v, ok, err = src.Lookup("SRC_TOKEN")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc=def", v)
+
+	_, ok, err = src.Lookup("MALFORMED")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestLoaderSourcePrecedence(t *testing.T) {
+	high := MapSource{"SRC_NAME": "high"}
+	low := MapSource{"SRC_NAME": "low", "SRC_PORT": "1234"}
+
+	var cfg sourceTestConfig
+	require.NoError(t, NewLoader(WithSources(high, low)).Load(&cfg))
+
+	assert.Equal(t, "high", cfg.Name)         // present in both, first source wins
+	assert.Equal(t, 1234, cfg.Port)           // only in the lower source
+	assert.Equal(t, "localhost", cfg.DB.Host) // in neither, default applies
+}
+
+func TestLoaderDefaultsToEnv(t *testing.T) {
+	t.Setenv("SRC_NAME", "from-env")
+
+	var cfg sourceTestConfig
+	require.NoError(t, NewLoader().Load(&cfg))
+	assert.Equal(t, "from-env", cfg.Name)
+}
+
+func TestLoaderRequiresPointer(t *testing.T) {
+	l := NewLoader()
+
+	err := l.Load(sourceTestConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pointer to struct")
+
+	var nilCfg *sourceTestConfig
+	require.Error(t, l.Load(nilCfg))
+}
+
+type failingSource struct{ err error }
+
+func (f failingSource) Lookup(string) (string, bool, error) { return "", false, f.err }
+
+func TestLoaderSourceError(t *testing.T) {
+	boom := errors.New("backend unavailable")
+	l := NewLoader(WithSources(failingSource{err: boom}))
+
+	var cfg sourceTestConfig
+	err := l.Load(&cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "SRC_NAME")
+}
+
+func TestDotenvSource(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, ".env")
+	second := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(first, []byte("SRC_DOTENV_ONLY=first\nSRC_DOTENV_BOTH=first\n"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("SRC_DOTENV_BOTH=second\nSRC_DOTENV_LOCAL=local\n"), 0o600))
+
+	src, err := DotenvSource(first, second)
+	require.NoError(t, err)
+
+	for key, want := range map[string]string{
+		"SRC_DOTENV_ONLY":  "first",
+		"SRC_DOTENV_BOTH":  "first",
+		"SRC_DOTENV_LOCAL": "local",
+	} {
+		v, ok, err := src.Lookup(key)
+		require.NoError(t, err)
+		assert.True(t, ok, key)
+		assert.Equal(t, want, v, key)
+	}
+
+	// Reading a .env file must not leak into the process environment
+	_, leaked := os.LookupEnv("SRC_DOTENV_ONLY")
+	assert.False(t, leaked)
+
+	_, err = DotenvSource(filepath.Join(dir, "missing.env"))
+	assert.Error(t, err)
+}
+
+// mountDir lays out dir like a Kubernetes ConfigMap or Secret volume: the files
+// live in a timestamped directory reached through the ..data symlink, and each
+// key is a symlink into ..data.
+func mountDir(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	versionDir := filepath.Join(dir, "..2024_01_01_"+version)
+	require.NoError(t, os.MkdirAll(versionDir, 0o755))
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(versionDir, name), []byte(contents), 0o644))
+	}
+
+	// Swap ..data atomically, as the kubelet does
+	tmp := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink(filepath.Base(versionDir), tmp))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+
+	for name := range files {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		require.NoError(t, os.Symlink(filepath.Join("..data", name), link))
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	secrets := t.TempDir()
+	config := t.TempDir()
+	mountDir(t, secrets, "a", map[string]string{"src.token": "s3cret\n"})
+	mountDir(t, config, "a", map[string]string{
+		"src.name":    "from-configmap\n",
+		"src.token":   "shadowed",
+		"src-db-host": "db.internal",
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(config, "SRC_PORT"), 0o755)) // directories are skipped
+
+	src := DirSource{
+		Dirs:      []string{secrets, config, filepath.Join(t.TempDir(), "missing")},
+		Normalize: EnvStyle,
+	}
+
+	var cfg sourceTestConfig
+	require.NoError(t, NewLoader(WithSources(src)).Load(&cfg))
+	assert.Equal(t, "from-configmap", cfg.Name)
+	assert.Equal(t, "s3cret", cfg.Token)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, 8080, cfg.Port)
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SRC_DB_HOST", "SRC_NAME", "SRC_TOKEN"}, keys)
+}
+
+func TestDirSourceSeesSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	mountDir(t, dir, "a", map[string]string{"SRC_NAME": "v1"})
+	src := DirSource{Dirs: []string{dir}}
+
+	v, ok, err := src.Lookup("SRC_NAME")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "v1", v)
+
+	mountDir(t, dir, "b", map[string]string{"SRC_NAME": "v2"})
+	v, _, err = src.Lookup("SRC_NAME")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", v)
+
+	// Bookkeeping entries are never keys
+	_, ok, err = src.Lookup("..data")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestEnvStyle(t *testing.T) {
+	assert.Equal(t, "DB_HOST", EnvStyle("db.host"))
+	assert.Equal(t, "API_KEY", EnvStyle("api-key"))
+	assert.Equal(t, "PORT", EnvStyle("PORT"))
+}
+
+func TestCredentialsSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src-token"), []byte("from-credential\n"), 0o400))
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	t.Setenv("SRC_TOKEN", "from-env")
+	t.Setenv("SRC_NAME", "from-env")
+
+	// Secret fields read the credential first, other fields never do
+	l := NewLoader()
+	var cfg sourceTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "from-credential", cfg.Token)
+	assert.Equal(t, "from-env", cfg.Name)
+
+	settings := make(map[string]FieldSetting)
+	for _, s := range l.Settings(cfg) {
+		settings[s.Path] = s
+	}
+	assert.Equal(t, "credential src-token", settings["Token"].Source)
+	assert.Equal(t, "env SRC_NAME", settings["Name"].Source)
+	assert.Equal(t, "default", settings["Port"].Source)
+
+	// Opting out reads secrets from the regular sources
+	cfg = sourceTestConfig{}
+	require.NoError(t, NewLoader(WithSecretSources()).Load(&cfg))
+	assert.Equal(t, "from-env", cfg.Token)
+}
+
+func TestCredentialsSourceFallsBackToEnv(t *testing.T) {
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	t.Setenv("SRC_TOKEN", "from-env")
+
+	src := CredentialsSource()
+	_, ok, err := src.Lookup("SRC_TOKEN")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	cfg, err := Load(sourceTestConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.Token)
+}
+
+func TestSettingsProvenance(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "app.env")
+	require.NoError(t, os.WriteFile(envFile, []byte("SRC_PORT=9090\n"), 0o600))
+	dotenv, err := DotenvSource(envFile)
+	require.NoError(t, err)
+
+	l := NewLoader(WithSources(MapSource{"SRC_NAME": "n"}, dotenv))
+	var cfg sourceTestConfig
+	require.NoError(t, l.Load(&cfg))
+
+	settings := make(map[string]string)
+	for _, s := range l.Settings(&cfg) {
+		settings[s.Path] = s.Source
+	}
+	assert.Equal(t, "map SRC_NAME", settings["Name"])
+	assert.Equal(t, "dotenv "+envFile, settings["Port"])
+	assert.Equal(t, "default", settings["DB.Host"])
+	assert.Empty(t, settings["Token"])
+
+	// The package-level Settings has no load to report on
+	for _, s := range Settings(cfg) {
+		assert.Empty(t, s.Source, s.Path)
+	}
+}
+
+func TestDirSourceFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/secrets/db-password": {Data: []byte("hunter2\n")},
+		"etc/config/db-password":  {Data: []byte("ignored")},
+		"etc/config/db.host":      {Data: []byte("db.internal")},
+		"etc/config/..data/x":     {Data: []byte("bookkeeping")},
+	}
+	src := DirSource{Dirs: []string{"etc/secrets", "etc/config", "etc/missing"}, Normalize: EnvStyle, FS: fsys}
+
+	v, ok, err := src.Lookup("DB_PASSWORD")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "hunter2", v)
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB_HOST", "DB_PASSWORD"}, keys)
+	assert.Equal(t, "file etc/config/db.host", src.Describe("DB_HOST"))
+}
+
+// countingFS counts the directory listings of an fs.FS.
+type countingFS struct {
+	fstest.MapFS
+	reads int
+}
+
+func (f *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.reads++
+	return f.MapFS.ReadDir(name)
+}
+
+func TestDirSourceListsOncePerLoad(t *testing.T) {
+	fsys := &countingFS{MapFS: fstest.MapFS{
+		"secrets/src-token":  {Data: []byte("s3cret")},
+		"config/src-name":    {Data: []byte("from-file")},
+		"config/src-db-host": {Data: []byte("db.internal")},
+	}}
+	src := DirSource{Dirs: []string{"secrets", "config"}, Normalize: EnvStyle, FS: fsys}
+
+	l := NewLoader(WithSources(src), WithSecretSources(src))
+	var cfg sourceTestConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "s3cret", cfg.Token)
+	assert.Equal(t, "from-file", cfg.Name)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	// Once per directory for each of the two lists the source is in
+	assert.Equal(t, 4, fsys.reads)
+
+	// The snapshot keeps its listing, files are read when looked up
+	snap := src.Snapshot()
+	fsys.MapFS["config/src-name"] = &fstest.MapFile{Data: []byte("changed")}
+	fsys.MapFS["config/src-port"] = &fstest.MapFile{Data: []byte("9090")}
+	v, _, err := snap.Lookup("SRC_NAME")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", v)
+	_, ok, err := snap.Lookup("SRC_PORT")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDotenvFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.env":  {Data: []byte("A=base\nB=base\n")},
+		"local.env": {Data: []byte("A=local\n")},
+	}
+	src, err := DotenvFSSource(fsys, "local.env", "base.env")
+	require.NoError(t, err)
+
+	v, _, _ := src.Lookup("A")
+	assert.Equal(t, "local", v)
+	v, _, _ = src.Lookup("B")
+	assert.Equal(t, "base", v)
+	assert.Equal(t, "dotenv base.env", describe(src, "B"))
+}
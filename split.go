@@ -0,0 +1,148 @@
+package gonfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitList splits raw on sep into trimmed, non-empty parts.
+//
+// Separators inside double quotes do not split, so `"Doe, John" <j@x>` stays
+// one part. A part that is quoted as a whole is unquoted RFC 4180 style, with
+// "" standing for a literal quote, and is kept even when empty.
+// A backslash escapes a following separator, quote or backslash; any other
+// backslash is kept as is, so regular expressions like \d+ pass through.
+func splitList(raw, sep string) ([]string, error) {
+	return splitParts(raw, []string{sep}, false)
+}
+
+// splitOuter splits the value of a nested list on its outer separator sep,
+// like splitList but leaving quotes and escapes in the parts, so that only
+// splitInner resolves them. With separators ";" and ",", `a\\,b;c` gives
+// the parts `a\\,b` and `c`, and then the elements `a\` and `b`.
+func splitOuter(raw, sep string) ([]string, error) {
+	return splitParts(raw, []string{sep}, true)
+}
+
+// splitInner splits a part returned by splitOuter on the inner separator sep,
+// like splitList. A backslash also escapes the outer separator, so `a\;b`
+// is the single element "a;b".
+func splitInner(raw, sep, outer string) ([]string, error) {
+	return splitParts(raw, []string{sep, outer}, false)
+}
+
+// splitParts splits raw on seps[0]. A backslash escapes any of seps. With
+// keep, parts are returned as written, only trimmed.
+func splitParts(raw string, seps []string, keep bool) ([]string, error) {
+	var parts []string
+	for start := 0; start >= 0; {
+		part, quoted, next, err := scanListPart(raw, start, seps, keep)
+		if err != nil {
+			return nil, err
+		}
+		// Skip empty parts
+		if quoted || part != "" {
+			parts = append(parts, part)
+		}
+		start = next
+	}
+	return parts, nil
+}
+
+// scanListPart scans the part of raw starting at start, up to the next
+// seps[0]. It returns the part, whether it was quoted as a whole, and the
+// offset just past the separator, or -1 when the part ends raw.
+func scanListPart(raw string, start int, seps []string, keep bool) (string, bool, int, error) {
+	sep := seps[0]
+
+	// A part quoted as a whole: spaces, "...", spaces, then a separator or the end
+	i := skipSpaces(raw, start)
+	if !keep && i < len(raw) && raw[i] == '"' {
+		if val, end, ok := scanQuoted(raw, i+1, seps); ok {
+			if strings.HasPrefix(raw[end:], sep) {
+				return val, true, end + len(sep), nil
+			}
+			end = skipSpaces(raw, end)
+			if end == len(raw) {
+				return val, true, -1, nil
+			}
+			if strings.HasPrefix(raw[end:], sep) {
+				return val, true, end + len(sep), nil
+			}
+		}
+	}
+
+	// Any other part: quotes are kept but protect separators
+	var b strings.Builder
+	inQuote := false
+	for i := start; i < len(raw); {
+		if raw[i] == '\\' {
+			if n := escapeLen(raw[i+1:], seps); n > 0 {
+				if keep {
+					b.WriteString(raw[i : i+1+n])
+				} else {
+					b.WriteString(raw[i+1 : i+1+n])
+				}
+				i += 1 + n
+				continue
+			}
+		}
+		if raw[i] == '"' {
+			inQuote = !inQuote
+		} else if !inQuote && strings.HasPrefix(raw[i:], sep) {
+			return strings.TrimSpace(b.String()), false, i + len(sep), nil
+		}
+		b.WriteByte(raw[i])
+		i++
+	}
+	if inQuote {
+		return "", false, 0, fmt.Errorf("unterminated quote in %q", raw[start:])
+	}
+	return strings.TrimSpace(b.String()), false, -1, nil
+}
+
+// scanQuoted reads a quoted string whose opening quote precedes raw[i]. It
+// returns the unquoted value, the offset past the closing quote and whether a
+// closing quote was found.
+func scanQuoted(raw string, i int, seps []string) (string, int, bool) {
+	var b strings.Builder
+	for i < len(raw) {
+		switch {
+		case raw[i] == '"' && i+1 < len(raw) && raw[i+1] == '"':
+			b.WriteByte('"')
+			i += 2
+		case raw[i] == '"':
+			return b.String(), i + 1, true
+		case raw[i] == '\\' && escapeLen(raw[i+1:], seps) > 0:
+			n := escapeLen(raw[i+1:], seps)
+			b.WriteString(raw[i+1 : i+1+n])
+			i += 1 + n
+		default:
+			b.WriteByte(raw[i])
+			i++
+		}
+	}
+	return "", 0, false
+}
+
+// escapeLen returns the length of the escaped text at the start of rest, the
+// text after a backslash, or 0 if the backslash escapes nothing.
+func escapeLen(rest string, seps []string) int {
+	for _, sep := range seps {
+		if strings.HasPrefix(rest, sep) {
+			return len(sep)
+		}
+	}
+	if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, `\`) {
+		return 1
+	}
+	return 0
+}
+
+// skipSpaces returns the offset of the first non-blank byte of raw at or after i.
+func skipSpaces(raw string, i int) int {
+	for i < len(raw) && (raw[i] == ' ' || raw[i] == '\t') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,147 @@
+package gonfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SQLSource is a Source over settings kept in a database table, one row per
+// key. Keys are looked up as they are, so a row keyed "DB_HOST" fills the
+// field tagged `env:"DB_HOST"`.
+//
+// The query returns the columns key, value and updated_at, and takes one
+// argument: the latest updated_at seen so far, the zero time at first. It
+// should return the rows updated at or after that time, so Refresh reads
+// only what changed since the last read:
+//
+//	SELECT key, value, updated_at FROM settings WHERE updated_at >= $1
+//
+// A NULL value removes the key. Rows deleted outright go unnoticed until
+// the source is created again, so mark settings removed by setting their
+// value to NULL instead.
+type SQLSource struct {
+	db    *sql.DB
+	query string
+
+	mu     sync.RWMutex
+	values map[string]string
+	since  time.Time // latest updated_at read
+}
+
+// NewSQLSource runs query against db and returns a source serving its rows.
+func NewSQLSource(ctx context.Context, db *sql.DB, query string) (*SQLSource, error) {
+	if db == nil || query == "" {
+		return nil, errors.New("sql source: db and query are required")
+	}
+	s := &SQLSource{db: db, query: query, values: make(map[string]string)}
+	if _, err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh implements Refresher, reading the rows updated since the last read.
+func (s *SQLSource) Refresh(ctx context.Context) (bool, error) {
+	s.mu.RLock()
+	since := s.since
+	s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, s.query, since)
+	if err != nil {
+		return false, fmt.Errorf("sql source: %w", err)
+	}
+	defer rows.Close()
+
+	type row struct {
+		key     string
+		value   sql.NullString
+		updated time.Time
+	}
+	var read []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.key, &r.value, &r.updated); err != nil {
+			return false, fmt.Errorf("sql source: %w", err)
+		}
+		read = append(read, r)
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("sql source: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var changed bool
+	for _, r := range read {
+		old, had := s.values[r.key]
+		switch {
+		case !r.value.Valid:
+			delete(s.values, r.key)
+			changed = changed || had
+		case !had || old != r.value.String:
+			s.values[r.key] = r.value.String
+			changed = true
+		}
+		if r.updated.After(s.since) {
+			s.since = r.updated
+		}
+	}
+	return changed, nil
+}
+
+// Lookup implements Source.
+func (s *SQLSource) Lookup(key string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok, nil
+}
+
+// Keys implements KeyLister.
+func (s *SQLSource) Keys() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Describe implements Describer.
+func (s *SQLSource) Describe(key string) string {
+	return "sql " + key
+}
+
+// Snapshot implements Snapshotter with a copy of the current values.
+func (s *SQLSource) Snapshot() Source {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sqlSnapshot(maps.Clone(s.values))
+}
+
+// sqlSnapshot is the view of an SQLSource during one load.
+type sqlSnapshot map[string]string
+
+// Lookup implements Source.
+func (m sqlSnapshot) Lookup(key string) (string, bool, error) {
+	v, ok := m[key]
+	return v, ok, nil
+}
+
+// Keys implements KeyLister.
+func (m sqlSnapshot) Keys() ([]string, error) {
+	return MapSource(m).Keys()
+}
+
+// Describe implements Describer.
+func (sqlSnapshot) Describe(key string) string {
+	return "sql " + key
+}
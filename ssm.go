@@ -0,0 +1,229 @@
+package gonfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSMConfig configures an SSMSource. Loaded with WithPrefix("AWS_") it reads
+// the variables the AWS SDKs use, like AWS_REGION and AWS_ACCESS_KEY_ID.
+type SSMConfig struct {
+	// Region of the parameters, like "eu-west-1".
+	Region string `env:"REGION" required:"true"`
+
+	// Endpoint overrides https://ssm.<region>.amazonaws.com, for local
+	// fakes and private endpoints.
+	Endpoint string `env:"ENDPOINT_URL_SSM"`
+
+	// Path is the hierarchy holding the configuration, like "/app/prod".
+	Path string `env:"SSM_PATH" required:"true"`
+
+	// Requests are signed with these credentials when AccessKeyID is set.
+	AccessKeyID     string `secret:"ACCESS_KEY_ID"`
+	SecretAccessKey string `secret:"SECRET_ACCESS_KEY"`
+	SessionToken    string `secret:"SESSION_TOKEN"`
+
+	// Timeout bounds each request.
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+
+	TLSConfig
+}
+
+// SSMSource is a Source over a hierarchy of parameters in AWS Systems
+// Manager Parameter Store, or a server speaking its JSON protocol. The
+// parameters beneath the path map onto the config struct by their
+// slash-separated names, so with the path "/app/prod" the parameter
+// "/app/prod/db/password" fills the field DB.Password. Each step is named by
+// the field's `ssm` tag or, without one, by its `prefix` tag less the
+// trailing '_' or its name. SecureString values are decrypted.
+//
+// Refresh reads the parameters again and reports whether they changed.
+// When it fails the source keeps serving the values it last read.
+type SSMSource struct {
+	cfg    SSMConfig
+	client *http.Client
+	now    func() time.Time
+
+	liveTree
+
+	mu     sync.Mutex // guards values
+	values map[string]string
+}
+
+// NewSSMSource reads the parameters under cfg.Path and returns a source serving them.
+func NewSSMSource(ctx context.Context, cfg SSMConfig) (*SSMSource, error) {
+	if cfg.Region == "" {
+		return nil, errors.New("ssm source: Region is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://ssm." + cfg.Region + ".amazonaws.com"
+	}
+	client, err := cfg.client(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("ssm source: %w", err)
+	}
+	s := &SSMSource{cfg: cfg, client: client, now: time.Now}
+	if _, err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ssmPage is one page of a GetParametersByPath response.
+type ssmPage struct {
+	Parameters []struct {
+		Name  string
+		Value string
+	}
+	NextToken string
+}
+
+// Refresh implements Refresher.
+func (s *SSMSource) Refresh(ctx context.Context) (bool, error) {
+	path := "/" + strings.Trim(s.cfg.Path, "/")
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	values := make(map[string]string)
+	var token string
+	for {
+		body := map[string]any{
+			"Path":           path,
+			"Recursive":      true,
+			"WithDecryption": true,
+			"MaxResults":     10,
+		}
+		if token != "" {
+			body["NextToken"] = token
+		}
+		var page ssmPage
+		if err := s.call(ctx, "GetParametersByPath", body, &page); err != nil {
+			return false, err
+		}
+		for _, p := range page.Parameters {
+			if key, ok := strings.CutPrefix(p.Name, prefix); ok && key != "" {
+				values[key] = p.Value
+			}
+		}
+		if token = page.NextToken; token == "" {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values != nil && maps.Equal(values, s.values) {
+		return false, nil
+	}
+	s.values = values
+	root := keyTree(values, func(key string) string { return "ssm " + prefix + key })
+	s.set(&treeSource{tag: "ssm", root: root, prefixNames: true})
+	return true, nil
+}
+
+// call invokes action with the JSON protocol, decoding the response into out.
+func (s *SSMSource) call(ctx context.Context, action string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM."+action)
+	if s.cfg.AccessKeyID != "" {
+		signV4(req, body, s.cfg, "ssm", s.now().UTC())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("ssm %s: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Type != "" {
+			// the type may carry a namespace, as in "com.amazonaws.ssm#AccessDeniedException"
+			e.Type = e.Type[strings.LastIndexByte(e.Type, '#')+1:]
+			return fmt.Errorf("ssm %s: %s: %s: %s", action, resp.Status, e.Type, e.Message)
+		}
+		return fmt.Errorf("ssm %s: %s", action, resp.Status)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("ssm %s: %w", action, err)
+	}
+	return nil
+}
+
+// signV4 signs req, whose payload is body, with AWS Signature Version 4,
+// covering the host and every header already set.
+func signV4(req *http.Request, body []byte, cfg SSMConfig, service string, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	payload := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		req.Method,
+		uri,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	date := amzDate[:8]
+	scope := date + "/" + cfg.Region + "/" + service + "/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := []byte("AWS4" + cfg.SecretAccessKey)
+	for _, part := range []string{date, cfg.Region, service, "aws4_request", toSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, hex.EncodeToString(key)))
+}
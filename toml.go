@@ -0,0 +1,807 @@
+package gonfig
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TOMLFileSource returns a Source backed by the TOML v1.0 file at path.
+// Tables and inline tables correspond to nested structs, arrays to slices and
+// arrays of tables to slices of structs; see PathSource for how fields are
+// named. Field names come from `toml` tags.
+//
+// Values are handed to the parsers in a canonical form: integers in decimal,
+// so 0xff reads as 255, and datetimes in RFC 3339, so they decode into
+// time.Time. Local datetimes and local dates are taken to be in UTC. Local
+// times such as 07:32:00 are kept as written.
+func TOMLFileSource(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTOMLSource(path, data)
+}
+
+// TOMLFSSource is like TOMLFileSource but reads the file at path in fsys, such
+// as an embed.FS holding defaults shipped with the binary.
+func TOMLFSSource(fsys fs.FS, path string) (Source, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTOMLSource(path, data)
+}
+
+// TOMLSource returns a Source backed by TOML data, like TOMLFileSource.
+func TOMLSource(data []byte) (Source, error) {
+	return parseTOMLSource("toml", data)
+}
+
+// parseTOMLSource parses data, naming locations after name.
+func parseTOMLSource(name string, data []byte) (Source, error) {
+	src := strings.TrimPrefix(string(data), "\uFEFF")
+	if !utf8.ValidString(src) {
+		return nil, fmt.Errorf("%s: invalid UTF-8", name)
+	}
+
+	p := &tomlParser{name: name, src: src, state: make(map[*node]tomlState)}
+	for i, c := range []byte(src) {
+		if c == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &treeSource{tag: "toml", root: root}, nil
+}
+
+// tomlState records how a table or array was defined, which decides whether
+// later headers and keys may add to it.
+type tomlState int
+
+const (
+	tomlImplicit      tomlState = iota // parent of a [header], may be defined later
+	tomlHeader                         // defined by [header] or [[header]]
+	tomlDotted                         // defined by dotted keys
+	tomlInline                         // inline table, closed to additions
+	tomlStatic                         // array value, closed to [[header]]
+	tomlArrayOfTables                  // array built by [[header]]
+)
+
+// tomlParser reads a TOML document into nodes.
+type tomlParser struct {
+	name  string
+	src   string
+	off   int
+	lines []int // offsets at which lines after the first start
+	cur   *node // table receiving key/value pairs
+	state map[*node]tomlState
+}
+
+// pos returns the "name:line:col" location of offset off.
+func (p *tomlParser) pos(off int) string {
+	line := sort.SearchInts(p.lines, off+1)
+	start := 0
+	if line > 0 {
+		start = p.lines[line-1]
+	}
+	return fmt.Sprintf("%s:%d:%d", p.name, line+1, utf8.RuneCountInString(p.src[start:off])+1)
+}
+
+// errorf returns an error located at offset off.
+func (p *tomlParser) errorf(off int, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", p.pos(off), fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.off >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.off]
+}
+
+// consume skips c if it comes next.
+func (p *tomlParser) consume(c byte) bool {
+	if p.peek() != c || p.eof() {
+		return false
+	}
+	p.off++
+	return true
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.src[p.off] == ' ' || p.src[p.off] == '\t') {
+		p.off++
+	}
+}
+
+// skipComment skips a comment up to, but not including, the end of the line.
+func (p *tomlParser) skipComment() error {
+	if p.peek() != '#' {
+		return nil
+	}
+	for !p.eof() && p.src[p.off] != '\n' {
+		if c := p.src[p.off]; isTOMLControl(c) && !(c == '\r' && strings.HasPrefix(p.src[p.off:], "\r\n")) {
+			return p.errorf(p.off, "control character %U in comment", c)
+		}
+		p.off++
+	}
+	return nil
+}
+
+// newline skips a line ending, reporting whether there was one.
+func (p *tomlParser) newline() bool {
+	switch {
+	case strings.HasPrefix(p.src[p.off:], "\n"):
+		p.off++
+	case strings.HasPrefix(p.src[p.off:], "\r\n"):
+		p.off += 2
+	default:
+		return false
+	}
+	return true
+}
+
+// endLine expects the rest of the line to be blank or a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	if err := p.skipComment(); err != nil {
+		return err
+	}
+	if !p.eof() && !p.newline() {
+		return p.errorf(p.off, "expected end of line, got %q", p.src[p.off])
+	}
+	return nil
+}
+
+// parse reads the whole document.
+func (p *tomlParser) parse() (*node, error) {
+	root := &node{kind: mappingNode, pos: p.name}
+	p.cur = root
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return root, nil
+		}
+		switch p.peek() {
+		case '#', '\n', '\r':
+		case '[':
+			if err := p.parseHeader(root); err != nil {
+				return nil, err
+			}
+		default:
+			if err := p.parseKeyValue(p.cur); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseHeader reads a [table] or [[array of tables]] header and makes its
+// table the current one.
+func (p *tomlParser) parseHeader(root *node) error {
+	start := p.off
+	p.off++
+	array := p.consume('[')
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if !p.consume(']') || array && !p.consume(']') {
+		return p.errorf(p.off, "expected ']' to close the table header")
+	}
+
+	pos := p.pos(start)
+	t := root
+	for _, k := range keys[:len(keys)-1] {
+		i := indexOf(t.keys, k)
+		if i < 0 {
+			n := &node{kind: mappingNode, pos: pos}
+			p.state[n] = tomlImplicit
+			t.keys = append(t.keys, k)
+			t.items = append(t.items, n)
+			t = n
+			continue
+		}
+		switch n := t.items[i]; {
+		case n.kind == mappingNode && p.state[n] != tomlInline:
+			t = n
+		case n.kind == sequenceNode && p.state[n] == tomlArrayOfTables:
+			t = n.items[len(n.items)-1]
+		default:
+			return p.errorf(start, "cannot define table %s: %q is already a value", strings.Join(keys, "."), k)
+		}
+	}
+
+	last := keys[len(keys)-1]
+	table := &node{kind: mappingNode, pos: pos}
+	p.state[table] = tomlHeader
+	i := indexOf(t.keys, last)
+
+	switch {
+	case array && i < 0:
+		list := &node{kind: sequenceNode, pos: pos, items: []*node{table}}
+		p.state[list] = tomlArrayOfTables
+		t.keys = append(t.keys, last)
+		t.items = append(t.items, list)
+	case array:
+		list := t.items[i]
+		if list.kind != sequenceNode || p.state[list] != tomlArrayOfTables {
+			return p.errorf(start, "cannot append to %s: not an array of tables", strings.Join(keys, "."))
+		}
+		list.items = append(list.items, table)
+	case i < 0:
+		t.keys = append(t.keys, last)
+		t.items = append(t.items, table)
+	default:
+		table = t.items[i]
+		if table.kind != mappingNode || p.state[table] != tomlImplicit {
+			return p.errorf(start, "table %s already defined", strings.Join(keys, "."))
+		}
+		table.pos = pos
+		p.state[table] = tomlHeader
+	}
+	p.cur = table
+	return nil
+}
+
+// parseKeyValue reads a key = value pair into table t.
+func (p *tomlParser) parseKeyValue(t *node) error {
+	start := p.off
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if !p.consume('=') {
+		return p.errorf(p.off, "expected '=' after key %s", strings.Join(keys, "."))
+	}
+	p.skipSpace()
+	v, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys[:len(keys)-1] {
+		i := indexOf(t.keys, k)
+		if i < 0 {
+			n := &node{kind: mappingNode, pos: p.pos(start)}
+			p.state[n] = tomlDotted
+			t.keys = append(t.keys, k)
+			t.items = append(t.items, n)
+			t = n
+			continue
+		}
+		n := t.items[i]
+		if n.kind != mappingNode || p.state[n] != tomlDotted {
+			return p.errorf(start, "cannot set %s: %q is already defined", strings.Join(keys, "."), k)
+		}
+		t = n
+	}
+
+	last := keys[len(keys)-1]
+	if indexOf(t.keys, last) >= 0 {
+		return p.errorf(start, "duplicate key %q", last)
+	}
+	t.keys = append(t.keys, last)
+	t.items = append(t.items, v)
+	return nil
+}
+
+// parseKey reads a possibly dotted key and the spaces around it.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		k, err := p.parseSimpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+		p.skipSpace()
+		if !p.consume('.') {
+			return keys, nil
+		}
+	}
+}
+
+// parseSimpleKey reads a bare or quoted key.
+func (p *tomlParser) parseSimpleKey() (string, error) {
+	switch {
+	case strings.HasPrefix(p.src[p.off:], `"""`), strings.HasPrefix(p.src[p.off:], "'''"):
+		return "", p.errorf(p.off, "keys cannot be multi-line strings")
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	}
+	start := p.off
+	for !p.eof() && isTOMLBareKeyChar(p.src[p.off]) {
+		p.off++
+	}
+	if p.off == start {
+		return "", p.errorf(p.off, "expected a key")
+	}
+	return p.src[start:p.off], nil
+}
+
+// parseValue reads any value.
+func (p *tomlParser) parseValue() (*node, error) {
+	start := p.off
+	n := &node{kind: scalarNode, pos: p.pos(start)}
+
+	var err error
+	switch {
+	case strings.HasPrefix(p.src[p.off:], `"""`):
+		n.value, err = p.parseMultilineString('"')
+	case strings.HasPrefix(p.src[p.off:], "'''"):
+		n.value, err = p.parseMultilineString('\'')
+	case p.peek() == '"':
+		n.value, err = p.parseBasicString()
+	case p.peek() == '\'':
+		n.value, err = p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray(n)
+	case p.peek() == '{':
+		return p.parseInlineTable(n)
+	default:
+		n.value, err = p.parseBareValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseArray reads an array value into n.
+func (p *tomlParser) parseArray(n *node) (*node, error) {
+	p.off++
+	n.kind = sequenceNode
+	p.state[n] = tomlStatic
+	for {
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return n, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, v)
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return n, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf(p.off, "expected ',' or ']' in array")
+		}
+	}
+}
+
+// skipArraySpace skips whitespace, newlines and comments inside an array.
+func (p *tomlParser) skipArraySpace() error {
+	for {
+		p.skipSpace()
+		if err := p.skipComment(); err != nil {
+			return err
+		}
+		if !p.newline() {
+			return nil
+		}
+	}
+}
+
+// parseInlineTable reads an inline table into n.
+func (p *tomlParser) parseInlineTable(n *node) (*node, error) {
+	p.off++
+	n.kind = mappingNode
+	p.skipSpace()
+	if !p.consume('}') {
+		for {
+			if err := p.parseKeyValue(n); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.consume('}') {
+				break
+			}
+			if !p.consume(',') {
+				return nil, p.errorf(p.off, "expected ',' or '}' in inline table")
+			}
+		}
+	}
+	p.closeInline(n)
+	return n, nil
+}
+
+// closeInline marks the tables in n, an inline table, as closed to additions.
+func (p *tomlParser) closeInline(n *node) {
+	if n.kind == mappingNode {
+		p.state[n] = tomlInline
+	}
+	for _, item := range n.items {
+		p.closeInline(item)
+	}
+}
+
+// parseBasicString reads a "..." string.
+func (p *tomlParser) parseBasicString() (string, error) {
+	start := p.off
+	p.off++
+	var b strings.Builder
+	for !p.eof() {
+		switch c := p.src[p.off]; {
+		case c == '"':
+			p.off++
+			return b.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		case c == '\n' || c == '\r':
+			return "", p.errorf(start, "unterminated string")
+		case isTOMLControl(c):
+			return "", p.errorf(p.off, "control character %U in string", c)
+		default:
+			b.WriteByte(c)
+			p.off++
+		}
+	}
+	return "", p.errorf(start, "unterminated string")
+}
+
+// parseLiteralString reads a '...' string.
+func (p *tomlParser) parseLiteralString() (string, error) {
+	start := p.off
+	p.off++
+	for !p.eof() {
+		switch c := p.src[p.off]; {
+		case c == '\'':
+			p.off++
+			return p.src[start+1 : p.off-1], nil
+		case c == '\n' || c == '\r':
+			return "", p.errorf(start, "unterminated string")
+		case isTOMLControl(c):
+			return "", p.errorf(p.off, "control character %U in string", c)
+		}
+		p.off++
+	}
+	return "", p.errorf(start, "unterminated string")
+}
+
+// parseMultilineString reads a multi-line string delimited by three quote
+// characters.
+func (p *tomlParser) parseMultilineString(quote byte) (string, error) {
+	start := p.off
+	delim := strings.Repeat(string(quote), 3)
+	p.off += 3
+	// A newline right after the opening delimiter is trimmed
+	p.newline()
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.src[p.off]
+		switch {
+		case strings.HasPrefix(p.src[p.off:], delim):
+			// Up to two quotes may come right before the closing delimiter
+			n := 0
+			for p.off+n < len(p.src) && p.src[p.off+n] == quote {
+				n++
+			}
+			if n > 5 {
+				return "", p.errorf(p.off, "too many quotes at end of string")
+			}
+			b.WriteString(strings.Repeat(string(quote), n-3))
+			p.off += n
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if p.skipLineEndingBackslash() {
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		case c == '\r' && !strings.HasPrefix(p.src[p.off:], "\r\n"), c != '\n' && c != '\r' && isTOMLControl(c):
+			return "", p.errorf(p.off, "control character %U in string", c)
+		default:
+			b.WriteByte(c)
+			p.off++
+		}
+	}
+	return "", p.errorf(start, "unterminated string")
+}
+
+// skipLineEndingBackslash skips a backslash at the end of a line in a
+// multi-line basic string, with all whitespace and newlines after it.
+func (p *tomlParser) skipLineEndingBackslash() bool {
+	i := p.off + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+		i++
+	}
+	if !strings.HasPrefix(p.src[i:], "\n") && !strings.HasPrefix(p.src[i:], "\r\n") {
+		return false
+	}
+	for i < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[i])) {
+		i++
+	}
+	p.off = i
+	return true
+}
+
+// parseEscape reads an escape sequence in a basic string into b.
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	start := p.off
+	p.off++
+	if p.eof() {
+		return p.errorf(start, "unterminated string")
+	}
+	c := p.src[p.off]
+	p.off++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.off+size > len(p.src) {
+			return p.errorf(start, "invalid escape %q", p.src[start:])
+		}
+		hex := p.src[p.off : p.off+size]
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || strings.ContainsAny(hex, "+-_") || !utf8.ValidRune(rune(code)) {
+			return p.errorf(start, "invalid escape %q", p.src[start:p.off+size])
+		}
+		b.WriteRune(rune(code))
+		p.off += size
+	default:
+		return p.errorf(start, "invalid escape %q", p.src[start:p.off])
+	}
+	return nil
+}
+
+// parseBareValue reads a boolean, number or datetime and returns it in
+// canonical form.
+func (p *tomlParser) parseBareValue() (string, error) {
+	start := p.off
+	for !p.eof() && isTOMLBareValueChar(p.src[p.off]) {
+		p.off++
+	}
+	// A date, a space and a time form one datetime
+	if isTOMLDate(p.src[start:p.off]) && p.off+1 < len(p.src) && p.src[p.off] == ' ' && isDigit(p.src[p.off+1]) {
+		p.off++
+		for !p.eof() && isTOMLBareValueChar(p.src[p.off]) {
+			p.off++
+		}
+	}
+	tok := p.src[start:p.off]
+
+	var (
+		v  string
+		ok bool
+	)
+	switch {
+	case tok == "":
+		return "", p.errorf(start, "expected a value")
+	case tok == "true" || tok == "false":
+		v, ok = tok, true
+	case isTOMLDate(tok) || len(tok) >= 8 && matchDigits(tok[:8], "dd:dd:dd"):
+		v, ok = tomlDatetime(tok)
+	case strings.HasPrefix(tok, "0x") || strings.HasPrefix(tok, "0o") || strings.HasPrefix(tok, "0b"):
+		v, ok = tomlPrefixedInt(tok)
+	case strings.ContainsAny(strings.TrimPrefix(tok, "-"), ".eEin"):
+		v, ok = tomlFloat(tok)
+	default:
+		v, ok = tomlInt(tok)
+	}
+	if !ok {
+		return "", p.errorf(start, "invalid value %q", tok)
+	}
+	return v, nil
+}
+
+// tomlInt validates a decimal integer and returns it without underscores.
+func tomlInt(tok string) (string, bool) {
+	digits := strings.TrimLeft(tok, "+-")
+	if len(tok)-len(digits) > 1 || !validTOMLDigits(digits, isDigit) || len(digits) > 1 && digits[0] == '0' {
+		return "", false
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(tok, "_", ""), 10, 64)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatInt(n, 10), true
+}
+
+// tomlPrefixedInt converts a hexadecimal, octal or binary integer to decimal.
+func tomlPrefixedInt(tok string) (string, bool) {
+	base, valid := 16, isHexDigit
+	switch tok[1] {
+	case 'o':
+		base, valid = 8, func(c byte) bool { return c >= '0' && c <= '7' }
+	case 'b':
+		base, valid = 2, func(c byte) bool { return c == '0' || c == '1' }
+	}
+	digits := tok[2:]
+	if !validTOMLDigits(digits, valid) {
+		return "", false
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatInt(n, 10), true
+}
+
+// tomlFloat validates a float and returns it without underscores.
+func tomlFloat(tok string) (string, bool) {
+	switch strings.TrimLeft(tok, "+-") {
+	case "inf", "nan":
+		return tok, len(tok) <= 4
+	}
+
+	mantissa, exp, hasExp := strings.Cut(strings.ToLower(tok), "e")
+	intPart, frac, hasFrac := strings.Cut(mantissa, ".")
+	digits := strings.TrimLeft(intPart, "+-")
+	switch {
+	case len(intPart)-len(digits) > 1, !validTOMLDigits(digits, isDigit), len(digits) > 1 && digits[0] == '0':
+		return "", false
+	case hasFrac && !validTOMLDigits(frac, isDigit):
+		return "", false
+	case hasExp && !validTOMLDigits(strings.TrimLeft(exp, "+-"), isDigit), len(exp)-len(strings.TrimLeft(exp, "+-")) > 1:
+		return "", false
+	case !hasFrac && !hasExp:
+		return "", false
+	}
+
+	v := strings.ReplaceAll(tok, "_", "")
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// validTOMLDigits reports whether s is digits accepted by valid, with single
+// underscores only between digits.
+func validTOMLDigits(s string, valid func(byte) bool) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' {
+			if i == 0 || i == len(s)-1 || s[i+1] == '_' {
+				return false
+			}
+			continue
+		}
+		if !valid(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlDatetime converts a TOML datetime to RFC 3339. Local datetimes and
+// dates are put in UTC; local times are returned as they are.
+func tomlDatetime(tok string) (string, bool) {
+	if !isTOMLDate(tok) {
+		// Local time
+		if !isTOMLTime(tok) {
+			return "", false
+		}
+		_, err := time.Parse("15:04:05", tok)
+		return tok, err == nil
+	}
+
+	date, clock := tok[:10], "00:00:00"
+	if len(tok) > 10 {
+		if !strings.ContainsRune("Tt ", rune(tok[10])) {
+			return "", false
+		}
+		clock = tok[11:]
+	}
+
+	zone := "Z"
+	switch {
+	case strings.HasSuffix(clock, "Z"), strings.HasSuffix(clock, "z"):
+		clock = clock[:len(clock)-1]
+	case len(clock) > 8 && strings.ContainsAny(clock[len(clock)-6:len(clock)-5], "+-"):
+		clock, zone = clock[:len(clock)-6], clock[len(clock)-6:]
+		if !matchDigits(zone[1:], "dd:dd") {
+			return "", false
+		}
+	}
+	if !isTOMLTime(clock) {
+		return "", false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, date+"T"+clock+zone)
+	if err != nil {
+		return "", false
+	}
+	return t.Format(time.RFC3339Nano), true
+}
+
+// isTOMLDate reports whether s starts with a full date, YYYY-MM-DD.
+func isTOMLDate(s string) bool {
+	return len(s) >= 10 && matchDigits(s[:10], "dddd-dd-dd")
+}
+
+// isTOMLTime reports whether s is HH:MM:SS with optional fractional seconds.
+func isTOMLTime(s string) bool {
+	if len(s) < 8 || !matchDigits(s[:8], "dd:dd:dd") {
+		return false
+	}
+	if frac, ok := strings.CutPrefix(s[8:], "."); ok {
+		return frac != "" && strings.Trim(frac, "0123456789") == ""
+	}
+	return len(s) == 8
+}
+
+// matchDigits reports whether s matches pattern, in which 'd' stands for
+// any digit and other bytes for themselves.
+func matchDigits(s, pattern string) bool {
+	if len(s) != len(pattern) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if pattern[i] == 'd' && !isDigit(s[i]) || pattern[i] != 'd' && s[i] != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '-'
+}
+
+// isTOMLBareValueChar reports whether c may be part of a boolean, number or
+// datetime.
+func isTOMLBareValueChar(c byte) bool {
+	return isTOMLBareKeyChar(c) || c == '+' || c == '.' || c == ':'
+}
+
+// isTOMLControl reports whether c is a control character other than tab,
+// which TOML forbids in strings and comments.
+func isTOMLControl(c byte) bool {
+	return c < 0x20 && c != '\t' || c == 0x7f
+}
@@ -0,0 +1,488 @@
+package gonfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathSource is implemented by structured sources, such as YAML files, whose
+// values sit at paths through nested mappings instead of under flat
+// environment variable names.
+//
+// The loader names a field by the path of struct field names leading to it,
+// taking each name from the struct tag returned by TagName, like
+// `yaml:"max_conns"`, or else from the Go field name. Elements of slices are
+// addressed by index ("0", "1", ...) and instances of maps by name.
+// Embedded structs and fields tagged `<tag>:",inline"` add no step to the path,
+// and fields tagged `<tag>:"-"` are not read from the source.
+//
+// The built-in sources match each step exactly or, failing that, ignoring
+// case, '_' and '-', so the field MaxConns matches max_conns, maxConns and
+// max-conns. A step that matches several keys only the second way, like
+// max_conns and max-conns side by side, fails the load.
+type PathSource interface {
+	Source
+
+	// TagName names the struct tag holding field names for this source.
+	TagName() string
+
+	// LookupPath returns the scalar value at path. A mapping, a sequence or
+	// a null value is not a scalar and reports false.
+	LookupPath(path []string) (value string, ok bool, err error)
+
+	// Children returns the keys of the mapping at path in source order, or
+	// "0", "1", ... for a sequence. It reports false when path does not lead
+	// to a mapping or sequence.
+	Children(path []string) (names []string, ok bool, err error)
+}
+
+// isPathSource reports whether src is a structured source.
+func isPathSource(src Source) bool {
+	_, ok := src.(PathSource)
+	return ok
+}
+
+// pathSeg is one step of a field's path in structured sources: a struct
+// field, or the index or name of a slice or map element.
+type pathSeg struct {
+	field *reflect.StructField
+	name  string
+}
+
+// appendSeg returns segs followed by seg without sharing segs' backing array,
+// so sibling fields never overwrite each other's paths.
+func appendSeg(segs []pathSeg, seg pathSeg) []pathSeg {
+	return append(segs[:len(segs):len(segs)], seg)
+}
+
+// sourcePath returns the path of segs in ps, naming fields by the struct tag
+// ps.TagName(). It reports false when a field on the path is tagged
+// `<tag>:"-"`.
+func sourcePath(segs []pathSeg, ps PathSource) ([]string, bool) {
+	tag := ps.TagName()
+	ts, _ := ps.(*treeSource)
+	prefixNames := ts != nil && ts.prefixNames
+
+	path := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		if seg.field == nil {
+			path = append(path, seg.name)
+			continue
+		}
+		name, opts, _ := strings.Cut(seg.field.Tag.Get(tag), ",")
+		prefix := strings.TrimSuffix(seg.field.Tag.Get("prefix"), "_")
+		switch {
+		case name == "-" && opts == "":
+			return nil, false
+		case name != "":
+			path = append(path, name)
+		case seg.field.Anonymous || hasOption(opts, "inline"):
+			// embedded and inlined structs add no step
+		case prefixNames && prefix != "":
+			path = append(path, prefix)
+		default:
+			path = append(path, seg.field.Name)
+		}
+	}
+	return path, true
+}
+
+// hasOption reports whether the comma-separated tag options opts include opt.
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePath builds a slice, array or map of type t from the mapping or
+// sequence at path in ps. Each element goes through the parser registry and
+// errors name the element's location.
+func (d *decoder) decodePath(ps PathSource, path []string, t reflect.Type, tag reflect.StructTag) (reflect.Value, error) {
+	names, ok, err := ps.Children(path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !ok {
+		// A scalar such as "a,b" is split like any other value
+		raw, _, err := ps.LookupPath(path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v, err := d.decodeValue(raw, t, tag)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s: %w", describePath(ps, path), err)
+		}
+		return v, nil
+	}
+	if format := tag.Get("format"); format != "" {
+		return reflect.Value{}, fmt.Errorf("%s: want a single %s-encoded value, got a mapping or sequence", describePath(ps, path), format)
+	}
+
+	var out reflect.Value
+	switch t.Kind() {
+	case reflect.Map:
+		out = reflect.MakeMapWithSize(t, len(names))
+	case reflect.Array:
+		if len(names) != t.Len() {
+			return reflect.Value{}, fmt.Errorf("%s: invalid %s: want %d elements, got %d", describePath(ps, path), t, t.Len(), len(names))
+		}
+		out = reflect.New(t).Elem()
+	default:
+		out = reflect.MakeSlice(t, len(names), len(names))
+	}
+
+	for i, name := range names {
+		elemPath := append(path[:len(path):len(path)], name)
+		elem, err := d.decodePathElem(ps, elemPath, t.Elem(), tag)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.Kind() != reflect.Map {
+			out.Index(i).Set(elem)
+			continue
+		}
+		k, err := d.parseValue(name, t.Key())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s: map key %q: %w", describePath(ps, elemPath), name, err)
+		}
+		out.SetMapIndex(k, elem)
+	}
+	return out, nil
+}
+
+// decodePathElem decodes one element of a mapping or sequence.
+func (d *decoder) decodePathElem(ps PathSource, path []string, t reflect.Type, tag reflect.StructTag) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if !d.reg.isCustomParsedType(t) {
+			return d.decodePath(ps, path, t, tag)
+		}
+	}
+
+	raw, ok, err := ps.LookupPath(path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%s: want a single value for %s", describePath(ps, path), t)
+	}
+	v, err := d.parseValue(raw, t)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%s: %w", describePath(ps, path), err)
+	}
+	return v, nil
+}
+
+// describePath describes the location of path in ps.
+func describePath(ps PathSource, path []string) string {
+	return describe(ps, strings.Join(path, "."))
+}
+
+// nodeKind tells the kinds of node apart.
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mappingNode
+	sequenceNode
+	nullNode
+)
+
+// node is one value of a structured configuration file.
+type node struct {
+	kind  nodeKind
+	value string   // scalar text
+	keys  []string // mapping keys, in source order
+	items []*node  // mapping values, parallel to keys, or sequence elements
+	pos   string   // location for messages, like "config.yaml:3:9"
+}
+
+// child returns the element of a mapping or sequence named by seg. Mapping
+// keys match exactly first and then ignoring case, '_' and '-'. Two keys that
+// match only the second way, like db_host and DB-HOST, are an error naming
+// both, since neither is more right than the other.
+func (n *node) child(seg string) (*node, error) {
+	switch n.kind {
+	case mappingNode:
+		if i := indexOf(n.keys, seg); i >= 0 {
+			return n.items[i], nil
+		}
+		norm := normalizeSegment(seg)
+		match := -1
+		for i, k := range n.keys {
+			if normalizeSegment(k) != norm {
+				continue
+			}
+			if match >= 0 {
+				return nil, fmt.Errorf("%q at %s and %q at %s both match %q",
+					n.keys[match], n.items[match].pos, k, n.items[i].pos, seg)
+			}
+			match = i
+		}
+		if match >= 0 {
+			return n.items[match], nil
+		}
+	case sequenceNode:
+		i, err := strconv.Atoi(seg)
+		if err == nil && i >= 0 && i < len(n.items) {
+			return n.items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeSegment folds case and drops '_' and '-' from a path step.
+func normalizeSegment(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+}
+
+// treeSource is a PathSource over a parsed configuration file.
+type treeSource struct {
+	tag    string
+	root   *node
+	strict bool // report keys no field reads
+
+	// prefixNames names untagged fields by their `prefix` tag, less the
+	// trailing '_', so [db] matches a field tagged `prefix:"DB_"`.
+	prefixNames bool
+
+	// repeatable marks sources whose repeated keys hold a list; scalar
+	// fields read the last value.
+	repeatable bool
+}
+
+// Strict returns a copy of src, a file source such as one returned by
+// JSONFileSource or YAMLFileSource, that fails the load with ErrUnknownKey for
+// every key no field reads, catching typos like "prot" for "port". A key
+// counts as read even when a higher-precedence source supplied the value.
+// Other sources are returned unchanged.
+func Strict(src Source) Source {
+	ts, ok := src.(*treeSource)
+	if !ok {
+		return src
+	}
+	strict := *ts
+	strict.strict = true
+	return &strict
+}
+
+// markRead records the nodes of strict sources that the field at segs
+// reads, whichever source ends up supplying its value.
+func (d *decoder) markRead(sources []Source, segs []pathSeg) {
+	for _, src := range sources {
+		ts, ok := src.(*treeSource)
+		if !ok || !ts.strict {
+			continue
+		}
+		path, ok := sourcePath(segs, ts)
+		if !ok {
+			continue
+		}
+		if n, _ := ts.find(path); n != nil {
+			if d.read == nil {
+				d.read = make(map[*node]bool)
+			}
+			d.read[n] = true
+		}
+	}
+}
+
+// checkUnknown fails the load for every value of a strict source that no
+// field read.
+func (d *decoder) checkUnknown() {
+	seen := make(map[*treeSource]bool)
+	for _, src := range append(append([]Source(nil), d.secretSources...), d.sources...) {
+		ts, ok := src.(*treeSource)
+		if !ok || !ts.strict || seen[ts] {
+			continue
+		}
+		seen[ts] = true
+		d.checkUnknownNode(ts.root, nil)
+	}
+}
+
+// checkUnknownNode reports the leaves under n, at path, that were not read.
+func (d *decoder) checkUnknownNode(n *node, path []string) {
+	if d.read[n] {
+		return
+	}
+	switch n.kind {
+	case mappingNode:
+		for i, k := range n.keys {
+			d.checkUnknownNode(n.items[i], append(path[:len(path):len(path)], k))
+		}
+	case sequenceNode:
+		for i, item := range n.items {
+			d.checkUnknownNode(item, append(path[:len(path):len(path)], strconv.Itoa(i)))
+		}
+	default:
+		key := strings.Join(path, ".")
+		d.fail(key, key, ErrUnknownKey, fmt.Errorf("%s: %w", n.pos, ErrUnknownKey))
+	}
+}
+
+// find returns the node at path, or nil.
+func (s *treeSource) find(path []string) (*node, error) {
+	n := s.root
+	for _, seg := range path {
+		var err error
+		if n, err = n.child(seg); n == nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// TagName implements PathSource.
+func (s *treeSource) TagName() string {
+	return s.tag
+}
+
+// Lookup implements Source for dot-separated paths like "db.host".
+func (s *treeSource) Lookup(key string) (string, bool, error) {
+	return s.LookupPath(strings.Split(key, "."))
+}
+
+// LookupPath implements PathSource.
+func (s *treeSource) LookupPath(path []string) (string, bool, error) {
+	n, err := s.find(path)
+	if err != nil {
+		return "", false, err
+	}
+	if n != nil && n.kind == sequenceNode && s.repeatable && len(n.items) > 0 {
+		n = n.items[len(n.items)-1]
+	}
+	if n == nil || n.kind != scalarNode {
+		return "", false, nil
+	}
+	return n.value, true, nil
+}
+
+// Children implements PathSource.
+func (s *treeSource) Children(path []string) ([]string, bool, error) {
+	n, err := s.find(path)
+	if n == nil {
+		return nil, false, err
+	}
+	switch n.kind {
+	case mappingNode:
+		return n.keys, true, nil
+	case sequenceNode:
+		names := make([]string, len(n.items))
+		for i := range n.items {
+			names[i] = strconv.Itoa(i)
+		}
+		return names, true, nil
+	}
+	return nil, false, nil
+}
+
+// Keys implements KeyLister with the dot-separated paths of all scalar
+// values, like "db.host" or "upstreams.0.host".
+func (s *treeSource) Keys() ([]string, error) {
+	var keys []string
+	var walk func(n *node, path string)
+	walk = func(n *node, path string) {
+		switch n.kind {
+		case scalarNode:
+			keys = append(keys, path)
+		case mappingNode:
+			for i, k := range n.keys {
+				walk(n.items[i], joinKey(path, k))
+			}
+		case sequenceNode:
+			for i, item := range n.items {
+				walk(item, joinKey(path, strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(s.root, "")
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// joinKey appends step to the dot-separated path.
+func joinKey(path, step string) string {
+	if path == "" {
+		return step
+	}
+	return path + "." + step
+}
+
+// Describe implements Describer with the location of the value at the
+// dot-separated path key.
+func (s *treeSource) Describe(key string) string {
+	if n, _ := s.find(strings.Split(key, ".")); n != nil {
+		return n.pos
+	}
+	return key
+}
+
+// keyTree builds a tree from values under slash-separated keys, like
+// "db/host", as held by key/value stores. pos names the location of a key.
+// A key that also has keys beneath it is dropped in favour of them, and a
+// mapping whose keys are exactly "0", "1", ... becomes a sequence.
+func keyTree(values map[string]string, pos func(key string) string) *node {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &node{kind: mappingNode, pos: pos("")}
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		n := root
+		for i, part := range parts {
+			var child *node
+			if j := indexOf(n.keys, part); j >= 0 {
+				child = n.items[j]
+			}
+			if i == len(parts)-1 {
+				if child == nil {
+					n.keys = append(n.keys, part)
+					n.items = append(n.items, &node{kind: scalarNode, value: values[key], pos: pos(key)})
+				}
+				break
+			}
+			if child == nil {
+				child = &node{kind: mappingNode, pos: pos(strings.Join(parts[:i+1], "/"))}
+				n.keys = append(n.keys, part)
+				n.items = append(n.items, child)
+			} else if child.kind != mappingNode {
+				*child = node{kind: mappingNode, pos: child.pos}
+			}
+			n = child
+		}
+	}
+	indexSequences(root)
+	return root
+}
+
+// indexSequences turns the mappings under n keyed "0", "1", ... into sequences.
+func indexSequences(n *node) {
+	if n.kind != mappingNode {
+		return
+	}
+	for _, item := range n.items {
+		indexSequences(item)
+	}
+	if len(n.keys) == 0 {
+		return
+	}
+	items := make([]*node, len(n.keys))
+	for i, k := range n.keys {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 || idx >= len(items) || items[idx] != nil || k != strconv.Itoa(idx) {
+			return
+		}
+		items[idx] = n.items[i]
+	}
+	n.kind, n.keys, n.items = sequenceNode, nil, items
+}
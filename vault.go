@@ -0,0 +1,456 @@
+package gonfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultConfig configures a VaultSource. Loaded with WithPrefix("VAULT_") it
+// reads the variables the Vault CLI uses, VAULT_ADDR and VAULT_TOKEN among them.
+type VaultConfig struct {
+	// Address of the server, like "https://vault.internal:8200".
+	Address string `env:"ADDR" default:"https://127.0.0.1:8200"`
+
+	// Namespace is sent as X-Vault-Namespace when set.
+	Namespace string `env:"NAMESPACE"`
+
+	// Token authenticates directly. Without it, RoleID and SecretID log in
+	// through the AppRole auth method mounted at AppRoleMount.
+	Token        string `secret:"TOKEN"`
+	RoleID       string `env:"ROLE_ID"`
+	SecretID     string `secret:"SECRET_ID"`
+	AppRoleMount string `env:"APPROLE_MOUNT" default:"approle"`
+
+	// Timeout bounds each request.
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+
+	TLSConfig
+}
+
+// VaultSource is a RefSource reading secrets through the Vault HTTP API.
+// Fields name the secret path and the key within it in a `vault` tag:
+//
+//	APIKey string `secret:"API_KEY" vault:"kv/data/app#api_key"`
+//
+// Paths of a KV version 2 engine include its "data/" segment, as in the
+// HTTP API. Other engines work too, so "database/creds/app#password" reads a
+// dynamic credential. Each path is read once and cached; a path that does
+// not exist leaves its fields to the other sources.
+//
+// Loader.Settings reports the version of a KV v2 secret along with its
+// reference, like "vault kv/data/app#api_key (version 3)".
+//
+// Refresh renews the token and any leases once half their time is up,
+// logging in again through AppRole when the token can no longer be renewed,
+// and reads the secrets again; use Watch to keep it running.
+type VaultSource struct {
+	cfg    VaultConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	token   vaultLease
+	secrets map[string]*vaultSecret // by path
+}
+
+// vaultLease tracks when a token or lease was granted and for how long.
+type vaultLease struct {
+	id        string
+	renewable bool
+	granted   time.Time
+	ttl       time.Duration // zero for no expiry
+}
+
+// due reports whether less than half of the lease is left at now.
+func (l vaultLease) due(now time.Time) bool {
+	return l.ttl > 0 && now.Sub(l.granted) >= l.ttl/2
+}
+
+// expired reports whether the lease has run out at now.
+func (l vaultLease) expired(now time.Time) bool {
+	return l.ttl > 0 && now.Sub(l.granted) >= l.ttl
+}
+
+// vaultSecret is a secret read from a path; data is nil when the path does not exist.
+type vaultSecret struct {
+	data    map[string]string
+	version int
+	lease   vaultLease
+}
+
+// NewVaultSource authenticates with the server at cfg.Address and returns a
+// source reading secrets from it.
+func NewVaultSource(ctx context.Context, cfg VaultConfig) (*VaultSource, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vault source: Address is required")
+	}
+	if cfg.Token == "" && cfg.RoleID == "" {
+		return nil, errors.New("vault source: Token or RoleID is required")
+	}
+	client, err := cfg.client(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("vault source: %w", err)
+	}
+	s := &VaultSource{cfg: cfg, client: client, now: time.Now, secrets: make(map[string]*vaultSecret)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg.Token == "" {
+		err = s.login(ctx)
+	} else {
+		err = s.lookupToken(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// vaultAuth is the auth block of login and token renewal responses.
+type vaultAuth struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// setToken records the token granted by an auth response.
+func (s *VaultSource) setToken(resp vaultAuth) {
+	s.token = vaultLease{
+		id:        resp.Auth.ClientToken,
+		renewable: resp.Auth.Renewable,
+		granted:   s.now(),
+		ttl:       time.Duration(resp.Auth.LeaseDuration) * time.Second,
+	}
+}
+
+// login obtains a token through AppRole.
+func (s *VaultSource) login(ctx context.Context) error {
+	s.token = vaultLease{}
+	var resp vaultAuth
+	body := map[string]string{"role_id": s.cfg.RoleID, "secret_id": s.cfg.SecretID}
+	if _, err := s.do(ctx, http.MethodPost, "auth/"+s.cfg.AppRoleMount+"/login", body, &resp); err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("vault: AppRole login returned no token")
+	}
+	s.setToken(resp)
+	return nil
+}
+
+// lookupToken checks cfg.Token and learns when it expires.
+func (s *VaultSource) lookupToken(ctx context.Context) error {
+	s.token = vaultLease{id: s.cfg.Token, granted: s.now()}
+	var resp struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}
+	if _, err := s.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, &resp); err != nil {
+		return err
+	}
+	s.token.renewable = resp.Data.Renewable
+	s.token.ttl = time.Duration(resp.Data.TTL) * time.Second
+	return nil
+}
+
+// renewToken extends the token once half its time is up, logging in again
+// when it cannot be renewed.
+func (s *VaultSource) renewToken(ctx context.Context) error {
+	now := s.now()
+	if !s.token.due(now) {
+		return nil
+	}
+	if s.token.renewable {
+		var resp vaultAuth
+		_, err := s.do(ctx, http.MethodPost, "auth/token/renew-self", nil, &resp)
+		if err == nil {
+			resp.Auth.ClientToken = s.token.id
+			s.setToken(resp)
+			return nil
+		}
+		if s.cfg.RoleID == "" {
+			return err
+		}
+	}
+	if s.cfg.RoleID != "" {
+		return s.login(ctx)
+	}
+	if s.token.expired(now) {
+		return errors.New("vault: token expired and cannot be renewed")
+	}
+	return nil
+}
+
+// read fetches the secret at path.
+func (s *VaultSource) read(ctx context.Context, path string) (*vaultSecret, error) {
+	var resp struct {
+		LeaseID       string                     `json:"lease_id"`
+		LeaseDuration int                        `json:"lease_duration"`
+		Renewable     bool                       `json:"renewable"`
+		Data          map[string]json.RawMessage `json:"data"`
+	}
+	status, err := s.do(ctx, http.MethodGet, path, nil, &resp)
+	if status == http.StatusNotFound {
+		return &vaultSecret{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &vaultSecret{lease: vaultLease{
+		id:        resp.LeaseID,
+		renewable: resp.Renewable,
+		granted:   s.now(),
+		ttl:       time.Duration(resp.LeaseDuration) * time.Second,
+	}}
+	data := resp.Data
+	// KV v2 nests the secret under data.data, beside data.metadata
+	if inner, ok := data["data"]; ok {
+		if meta, ok := data["metadata"]; ok {
+			var kv map[string]json.RawMessage
+			if err := json.Unmarshal(inner, &kv); err != nil {
+				return nil, fmt.Errorf("vault %s: %w", path, err)
+			}
+			var m struct {
+				Version int `json:"version"`
+			}
+			if err := json.Unmarshal(meta, &m); err != nil {
+				return nil, fmt.Errorf("vault %s: %w", path, err)
+			}
+			data, secret.version = kv, m.Version
+		}
+	}
+
+	secret.data = make(map[string]string, len(data))
+	for k, raw := range data {
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			// numbers, booleans and objects keep their JSON text
+			v = string(raw)
+		}
+		secret.data[k] = v
+	}
+	return secret, nil
+}
+
+// renewLease extends the lease of secret, or reads path again when the lease
+// cannot be renewed. It reports whether the secret's values changed.
+func (s *VaultSource) renewLease(ctx context.Context, path string, secret *vaultSecret) (bool, error) {
+	if secret.lease.id != "" && !secret.lease.due(s.now()) {
+		return false, nil
+	}
+	if secret.lease.id != "" && secret.lease.renewable {
+		var resp struct {
+			LeaseDuration int  `json:"lease_duration"`
+			Renewable     bool `json:"renewable"`
+		}
+		body := map[string]string{"lease_id": secret.lease.id}
+		if _, err := s.do(ctx, http.MethodPut, "sys/leases/renew", body, &resp); err == nil {
+			secret.lease.granted = s.now()
+			secret.lease.ttl = time.Duration(resp.LeaseDuration) * time.Second
+			secret.lease.renewable = resp.Renewable
+			return false, nil
+		}
+	}
+
+	fresh, err := s.read(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	changed := fresh.version != secret.version || !maps.Equal(fresh.data, secret.data)
+	s.secrets[path] = fresh
+	return changed, nil
+}
+
+// Refresh implements Refresher.
+func (s *VaultSource) Refresh(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.renewToken(ctx); err != nil {
+		return false, err
+	}
+
+	var (
+		changed bool
+		errs    []error
+	)
+	for path, secret := range s.secrets {
+		c, err := s.renewLease(ctx, path, secret)
+		changed = changed || c
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// do sends a request to the API endpoint path with body encoded as JSON,
+// decoding a successful response into out. It returns the response status.
+func (s *VaultSource) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		r = bytes.NewReader(data)
+	}
+	url := strings.TrimSuffix(s.cfg.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return 0, err
+	}
+	if s.token.id != "" {
+		req.Header.Set("X-Vault-Token", s.token.id)
+	}
+	if s.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("vault %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &e) == nil && len(e.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault %s %s: %s: %s", method, path, resp.Status, strings.Join(e.Errors, "; "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault %s %s: %s", method, path, resp.Status)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("vault %s %s: %w", method, path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// RefTag implements RefSource; fields are located by their `vault` tags.
+func (s *VaultSource) RefTag() string {
+	return "vault"
+}
+
+// Lookup implements Source for references like "kv/data/app#api_key",
+// reading and caching the secret at the path on first use. Other keys are
+// never present.
+func (s *VaultSource) Lookup(ref string) (string, bool, error) {
+	path, key, ok := splitVaultRef(ref)
+	if !ok {
+		return "", false, nil
+	}
+	secret, err := s.secret(path)
+	if err != nil {
+		return "", false, err
+	}
+	v, ok := secret.data[key]
+	return v, ok, nil
+}
+
+// splitVaultRef splits a reference like "kv/data/app#api_key" into the
+// secret's path and the key within it.
+func splitVaultRef(ref string) (path, key string, ok bool) {
+	path, key, ok = strings.Cut(ref, "#")
+	return path, key, ok && path != "" && key != ""
+}
+
+// secret returns the secret at path, reading it on first use.
+func (s *VaultSource) secret(path string) (*vaultSecret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[path]
+	if !ok {
+		var err error
+		if secret, err = s.read(context.Background(), path); err != nil {
+			return nil, err
+		}
+		s.secrets[path] = secret
+	}
+	return secret, nil
+}
+
+// Describe implements Describer, adding the version of KV v2 secrets.
+func (s *VaultSource) Describe(ref string) string {
+	path, _, _ := strings.Cut(ref, "#")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return describeVault(ref, s.secrets[path])
+}
+
+// describeVault describes ref, read from secret, which may be nil.
+func describeVault(ref string, secret *vaultSecret) string {
+	if secret != nil && secret.version > 0 {
+		return fmt.Sprintf("vault %s (version %d)", ref, secret.version)
+	}
+	return "vault " + ref
+}
+
+// Snapshot implements Snapshotter. The snapshot keeps each secret it reads,
+// so all fields of one load see the same version of it.
+func (s *VaultSource) Snapshot() Source {
+	return &vaultSnapshot{src: s, pinned: make(map[string]*vaultSecret)}
+}
+
+// vaultSnapshot is the view of a VaultSource during one load.
+type vaultSnapshot struct {
+	src *VaultSource
+
+	mu     sync.Mutex
+	pinned map[string]*vaultSecret // by path
+}
+
+// RefTag implements RefSource.
+func (v *vaultSnapshot) RefTag() string {
+	return v.src.RefTag()
+}
+
+// Lookup implements Source.
+func (v *vaultSnapshot) Lookup(ref string) (string, bool, error) {
+	path, key, ok := splitVaultRef(ref)
+	if !ok {
+		return "", false, nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	secret, ok := v.pinned[path]
+	if !ok {
+		var err error
+		if secret, err = v.src.secret(path); err != nil {
+			return "", false, err
+		}
+		v.pinned[path] = secret
+	}
+	value, ok := secret.data[key]
+	return value, ok, nil
+}
+
+// Describe implements Describer.
+func (v *vaultSnapshot) Describe(ref string) string {
+	path, _, _ := strings.Cut(ref, "#")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return describeVault(ref, v.pinned[path])
+}
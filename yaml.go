@@ -0,0 +1,162 @@
+package gonfig
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLFileSource returns a Source backed by the YAML file at path.
+// Nested mappings correspond to nested structs and sequences to slices; see
+// PathSource for how fields are named. Field names come from `yaml` tags.
+//
+// Layer it below EnvSource so environment variables override the file, while
+// the file still overrides `default` tags:
+//
+//	file, err := gonfig.YAMLFileSource("config.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	l := gonfig.NewLoader(gonfig.WithSources(gonfig.EnvSource(), file))
+//
+// Values that fail to parse are reported with their line and column.
+func YAMLFileSource(path string) (Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseYAMLSource(path, data)
+}
+
+// YAMLFSSource is like YAMLFileSource but reads the file at path in fsys, such
+// as an embed.FS holding defaults shipped with the binary.
+func YAMLFSSource(fsys fs.FS, path string) (Source, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseYAMLSource(path, data)
+}
+
+// YAMLSource returns a Source backed by YAML data, like YAMLFileSource.
+func YAMLSource(data []byte) (Source, error) {
+	return parseYAMLSource("yaml", data)
+}
+
+// parseYAMLSource parses data, naming locations after name.
+func parseYAMLSource(name string, data []byte) (Source, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	root := &node{kind: mappingNode, pos: name}
+	if len(doc.Content) > 0 {
+		n, err := yamlToNode(name, doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		if n.kind != mappingNode && n.kind != nullNode {
+			return nil, fmt.Errorf("%s: top level must be a mapping", n.pos)
+		}
+		if n.kind == mappingNode {
+			root = n
+		}
+	}
+	return &treeSource{tag: "yaml", root: root}, nil
+}
+
+// yamlToNode converts a yaml.v3 node, resolving aliases and merge keys.
+func yamlToNode(name string, y *yaml.Node) (*node, error) {
+	n := &node{pos: fmt.Sprintf("%s:%d:%d", name, y.Line, y.Column)}
+
+	switch y.Kind {
+	case yaml.AliasNode:
+		return yamlToNode(name, y.Alias)
+
+	case yaml.ScalarNode:
+		if y.Tag == "!!null" {
+			n.kind = nullNode
+			return n, nil
+		}
+		n.kind = scalarNode
+		n.value = y.Value
+
+	case yaml.SequenceNode:
+		n.kind = sequenceNode
+		for _, item := range y.Content {
+			c, err := yamlToNode(name, item)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, c)
+		}
+
+	case yaml.MappingNode:
+		n.kind = mappingNode
+		explicit := make(map[string]bool)
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			k, v := y.Content[i], y.Content[i+1]
+			c, err := yamlToNode(name, v)
+			if err != nil {
+				return nil, err
+			}
+			// Merge keys (<<: *base) contribute the keys the mapping lacks
+			if k.Tag == "!!merge" {
+				mergeNode(n, c)
+				continue
+			}
+			if k.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s:%d:%d: mapping keys must be scalars", name, k.Line, k.Column)
+			}
+			if explicit[k.Value] {
+				return nil, fmt.Errorf("%s:%d:%d: duplicate key %q", name, k.Line, k.Column, k.Value)
+			}
+			explicit[k.Value] = true
+			setKey(n, k.Value, c)
+		}
+
+	default:
+		return nil, fmt.Errorf("%s: unsupported YAML node", n.pos)
+	}
+	return n, nil
+}
+
+// mergeNode adds the keys of src, a mapping or sequence of mappings, that dst lacks.
+func mergeNode(dst, src *node) {
+	switch src.kind {
+	case mappingNode:
+		for i, k := range src.keys {
+			if indexOf(dst.keys, k) < 0 {
+				dst.keys = append(dst.keys, k)
+				dst.items = append(dst.items, src.items[i])
+			}
+		}
+	case sequenceNode:
+		for _, item := range src.items {
+			mergeNode(dst, item)
+		}
+	}
+}
+
+// setKey sets key in mapping n, replacing a key merged in earlier.
+func setKey(n *node, key string, v *node) {
+	if i := indexOf(n.keys, key); i >= 0 {
+		n.items[i] = v
+		return
+	}
+	n.keys = append(n.keys, key)
+	n.items = append(n.items, v)
+}
+
+// indexOf returns the index of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}